@@ -0,0 +1,37 @@
+// Command repair-likes recomputes the likes counter and the reaction counts of
+// every post from the rows in post_reactions. Run it after restoring a backup
+// or when the counters look wrong.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	// The .env file is optional here, DB_URL can come from the environment
+	_ = godotenv.Load()
+
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		log.Fatal("DB_URL must be set")
+	}
+
+	dbConn, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		log.Fatalf("Error opening database: %s", err)
+	}
+	defer dbConn.Close()
+
+	fixed, err := database.New(dbConn).RecountPostReactions(context.Background())
+	if err != nil {
+		log.Fatalf("Can't recount likes: %s", err)
+	}
+	log.Printf("Fixed the likes and reaction counts of %d posts", fixed)
+}
@@ -12,3 +12,5 @@ require (
 	github.com/google/uuid v1.6.0
 	golang.org/x/crypto v0.31.0
 )
+
+require golang.org/x/sys v0.28.0 // indirect
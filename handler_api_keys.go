@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func databaseAPIKeyToAPIKey(apiKey database.ApiKey) APIKey {
+	var lastUsedAt *time.Time
+	if apiKey.LastUsedAt.Valid {
+		lastUsedAt = &apiKey.LastUsedAt.Time
+	}
+
+	return APIKey{
+		ID:         apiKey.ID,
+		CreatedAt:  apiKey.CreatedAt,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		LastUsedAt: lastUsedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	type response struct {
+		APIKey
+		Key string `json:"key"`
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerCreateAPIKey", err)
+		return
+	}
+
+	if params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "name is required", nil)
+		return
+	}
+
+	scopes, err := auth.ParseScopes(params.Scopes)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	key, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't generate api key - handlerCreateAPIKey", err)
+		return
+	}
+
+	scopeNames := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scopeNames = append(scopeNames, string(scope))
+	}
+
+	apiKey, err := cfg.db.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+		ID:      uuid.New(),
+		UserID:  userID,
+		Name:    params.Name,
+		Prefix:  auth.APIKeyDisplayPrefix(key),
+		KeyHash: auth.HashToken(key),
+		Scopes:  scopeNames,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't save api key - handlerCreateAPIKey", err)
+		return
+	}
+
+	// The plain key is only ever shown here, we keep just its hash
+	respondWithJSON(w, http.StatusCreated, response{
+		APIKey: databaseAPIKeyToAPIKey(apiKey),
+		Key:    key,
+	})
+}
+
+func (cfg *apiConfig) handlerListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	apiKeys, err := cfg.db.ListActiveAPIKeysByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list api keys - handlerListAPIKeys", err)
+		return
+	}
+
+	response := make([]APIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		response = append(response, databaseAPIKeyToAPIKey(apiKey))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyIDString := r.PathValue("key_id")
+	keyID, err := uuid.Parse(keyIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse api key id - handlerRevokeAPIKey", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	_, err = cfg.db.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{
+		ID:     keyID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find api key - handlerRevokeAPIKey", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't revoke api key - handlerRevokeAPIKey", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
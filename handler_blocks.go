@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID `json:"muter_id"`
+	MutedID   uuid.UUID `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BlockedUser - an entry of my block list
+type BlockedUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"blocked_at"`
+}
+
+// MutedUser - an entry of my mute list
+type MutedUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"muted_at"`
+}
+
+// viewerID - the logged in user for queries that hide posts of blocked,
+// muted and private users and posts with a limited visibility. Anonymous
+// viewers only see public and unlisted posts of public accounts.
+func viewerID(ctx context.Context) uuid.NullUUID {
+	userID, ok := userIDFromContext(ctx)
+	return uuid.NullUUID{UUID: userID, Valid: ok}
+}
+
+// canSeePost - posts of users I blocked or who blocked me are hidden from me,
+// so are the posts of private accounts I don't follow and posts whose
+// visibility leaves me out. Handlers answer 404 for them like for a missing
+// post.
+func (cfg *apiConfig) canSeePost(ctx context.Context, post database.Post) (bool, error) {
+	return cfg.db.PostVisible(ctx, database.PostVisibleParams{
+		PostID:   post.ID,
+		ViewerID: viewerID(ctx),
+	})
+}
+
+// handlerBlockUser - blocks user_id. Follows between us are removed in both
+// directions and neither of us sees the other's posts anymore.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerBlockUser", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	if blockedID == userID {
+		respondWithError(w, http.StatusBadRequest, "you can't block yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), blockedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the user - handlerBlockUser", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the user - handlerBlockUser", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerBlockUser", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	block, err := qtx.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "you already blocked this user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't block the user - handlerBlockUser", err)
+		return
+	}
+
+	follows, err := qtx.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+		UserID:  userID,
+		OtherID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't remove follows - handlerBlockUser", err)
+		return
+	}
+	for _, follow := range follows {
+		err = qtx.UpdateFollowCounts(r.Context(), database.UpdateFollowCountsParams{
+			FollowerID: follow.FollowerID,
+			FolloweeID: follow.FolloweeID,
+			Delta:      -1,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't update follow counts - handlerBlockUser", err)
+			return
+		}
+	}
+
+	err = qtx.DeleteFollowRequestsBetween(r.Context(), database.DeleteFollowRequestsBetweenParams{
+		UserID:  userID,
+		OtherID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't remove follow requests - handlerBlockUser", err)
+		return
+	}
+
+	for _, entries := range []database.DeleteTimelineEntriesByAuthorParams{
+		{UserID: userID, AuthorID: blockedID},
+		{UserID: blockedID, AuthorID: userID},
+	} {
+		err = qtx.DeleteTimelineEntriesByAuthor(r.Context(), entries)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't clean up the timelines - handlerBlockUser", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerBlockUser", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Block(block))
+}
+
+// handlerUnblockUser - removed follows aren't restored
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerUnblockUser", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	_, err = cfg.db.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "you haven't blocked this user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't unblock the user - handlerUnblockUser", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListBlocks - a page of the users I blocked, the most recent first
+func (cfg *apiConfig) handlerListBlocks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	args := database.ListBlocksDescParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []BlockedUser{}
+	if page.Descending() {
+		rows, err := cfg.db.ListBlocksDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list blocked users - handlerListBlocks", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, BlockedUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListBlocksAsc(r.Context(), database.ListBlocksAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list blocked users - handlerListBlocks", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, BlockedUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, func(user BlockedUser) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}))
+}
+
+// handlerMuteUser - posts of user_id are hidden from my timelines, they can
+// still be opened directly and the user doesn't know about it
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerMuteUser", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	if mutedID == userID {
+		respondWithError(w, http.StatusBadRequest, "you can't mute yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), mutedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the user - handlerMuteUser", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the user - handlerMuteUser", err)
+		return
+	}
+
+	mute, err := cfg.db.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "you already muted this user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't mute the user - handlerMuteUser", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Mute(mute))
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerUnmuteUser", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	_, err = cfg.db.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "you haven't muted this user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't unmute the user - handlerUnmuteUser", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListMutes - a page of the users I muted, the most recent first
+func (cfg *apiConfig) handlerListMutes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	args := database.ListMutesDescParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []MutedUser{}
+	if page.Descending() {
+		rows, err := cfg.db.ListMutesDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list muted users - handlerListMutes", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, MutedUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListMutesAsc(r.Context(), database.ListMutesAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list muted users - handlerListMutes", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, MutedUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, func(user MutedUser) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/mailer"
+)
+
+const emailVerificationTokenTTL = time.Hour * 24
+
+// sendEmailVerification - mails a token that proves the user owns email,
+// earlier tokens of the user stop working
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.db.InvalidateEmailVerificationTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = cfg.db.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(emailVerificationTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Please confirm that this is your email address.\n\nYour verification token is: %s\n\nIt expires in %s.",
+			token,
+			emailVerificationTokenTTL,
+		),
+	})
+}
+
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+	type response struct {
+		Me
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerVerifyEmail", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerVerifyEmail", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	verification, err := qtx.ConsumeEmailVerificationToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "verification token is invalid or expired - handlerVerifyEmail", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't use verification token - handlerVerifyEmail", err)
+		return
+	}
+
+	// Fails when the user asked for another email after this token was sent
+	user, err := qtx.ConfirmUserEmail(r.Context(), database.ConfirmUserEmailParams{
+		Email: verification.Email,
+		ID:    verification.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "email is no longer waiting for verification - handlerVerifyEmail", err)
+			return
+		}
+		respondWithError(w, http.StatusConflict, "can't confirm email - handlerVerifyEmail", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit email verification - handlerVerifyEmail", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Me: databaseUserToMe(user),
+	})
+}
+
+func (cfg *apiConfig) handlerResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	email := user.PendingEmail.String
+	if !user.PendingEmail.Valid {
+		if user.EmailVerifiedAt.Valid {
+			respondWithError(w, http.StatusBadRequest, "email is already verified - handlerResendEmailVerification", nil)
+			return
+		}
+		email = user.Email
+	}
+
+	err := cfg.sendEmailVerification(r.Context(), user.ID, email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't send verification email - handlerResendEmailVerification", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
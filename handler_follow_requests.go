@@ -0,0 +1,178 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+// handlerListFollowRequests - a page of the pending requests to follow me,
+// the most recent first
+func (cfg *apiConfig) handlerListFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	args := database.ListFollowRequestsDescParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []FollowRequester{}
+	if page.Descending() {
+		rows, err := cfg.db.ListFollowRequestsDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list follow requests - handlerListFollowRequests", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, FollowRequester(row))
+		}
+	} else {
+		rows, err := cfg.db.ListFollowRequestsAsc(r.Context(), database.ListFollowRequestsAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list follow requests - handlerListFollowRequests", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, FollowRequester(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, func(user FollowRequester) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}))
+}
+
+// handlerApproveFollowRequest - turns the request of user_id into a follow
+func (cfg *apiConfig) handlerApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerApproveFollowRequest", err)
+		return
+	}
+
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerApproveFollowRequest", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the follow request - handlerApproveFollowRequest", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't remove the follow request - handlerApproveFollowRequest", err)
+		return
+	}
+
+	// The requester can follow me already, when I went public and private
+	// again in between. The stale request is dropped and the follow kept.
+	follow, err := qtx.GetFollow(r.Context(), database.GetFollowParams{
+		FollowerID: requesterID,
+		FolloweeID: user.ID,
+	})
+	if err == nil {
+		err = tx.Commit()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerApproveFollowRequest", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, Follow(follow))
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "can't get the follow - handlerApproveFollowRequest", err)
+		return
+	}
+
+	follow, err = qtx.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: requesterID,
+		FolloweeID: user.ID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "this user already follows you", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't create the follow - handlerApproveFollowRequest", err)
+		return
+	}
+
+	err = qtx.UpdateFollowCounts(r.Context(), database.UpdateFollowCountsParams{
+		FollowerID: requesterID,
+		FolloweeID: user.ID,
+		Delta:      1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't update follow counts - handlerApproveFollowRequest", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerApproveFollowRequest", err)
+		return
+	}
+
+	cfg.backfillTimeline(r.Context(), requesterID, user.ID)
+
+	respondWithJSON(w, http.StatusCreated, Follow(follow))
+}
+
+// handlerRejectFollowRequest - drops the request of user_id, they can ask again
+func (cfg *apiConfig) handlerRejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerRejectFollowRequest", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	_, err = cfg.db.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the follow request - handlerRejectFollowRequest", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't reject the follow request - handlerRejectFollowRequest", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
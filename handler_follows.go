@@ -0,0 +1,367 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID `json:"requester_id"`
+	TargetID    uuid.UUID `json:"target_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FollowRequester - an entry of my pending follow requests
+type FollowRequester struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"requested_at"`
+}
+
+// FollowUser - an entry of a followers or following list
+type FollowUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"followed_at"`
+}
+
+// handlerFollowUser - follows user_id. Following a private account only
+// sends a follow request, see handlerApproveFollowRequest.
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerFollowUser", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "you can't follow yourself", nil)
+		return
+	}
+
+	followee, err := cfg.db.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the user - handlerFollowUser", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the user - handlerFollowUser", err)
+		return
+	}
+
+	blocked, err := cfg.db.UsersBlocked(r.Context(), database.UsersBlockedParams{
+		UserID:  userID,
+		OtherID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check blocks - handlerFollowUser", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "you can't follow this user", nil)
+		return
+	}
+
+	if followee.IsPrivate {
+		cfg.requestFollow(w, r, userID, followeeID)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerFollowUser", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	follow, err := qtx.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "you already follow this user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't follow the user - handlerFollowUser", err)
+		return
+	}
+
+	err = qtx.UpdateFollowCounts(r.Context(), database.UpdateFollowCountsParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+		Delta:      1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't update follow counts - handlerFollowUser", err)
+		return
+	}
+
+	// A request left from when the account was private isn't needed anymore
+	_, err = qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{
+		RequesterID: userID,
+		TargetID:    followeeID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "can't remove the follow request - handlerFollowUser", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerFollowUser", err)
+		return
+	}
+
+	cfg.backfillTimeline(r.Context(), userID, followeeID)
+
+	respondWithJSON(w, http.StatusCreated, Follow(follow))
+}
+
+// requestFollow - asks a private account to approve me as a follower
+func (cfg *apiConfig) requestFollow(w http.ResponseWriter, r *http.Request, userID, targetID uuid.UUID) {
+	_, err := cfg.db.GetFollow(r.Context(), database.GetFollowParams{
+		FollowerID: userID,
+		FolloweeID: targetID,
+	})
+	if err == nil {
+		respondWithError(w, http.StatusConflict, "you already follow this user", nil)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "can't get the follow - handlerFollowUser", err)
+		return
+	}
+
+	request, err := cfg.db.CreateFollowRequest(r.Context(), database.CreateFollowRequestParams{
+		RequesterID: userID,
+		TargetID:    targetID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "you already asked to follow this user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't request to follow the user - handlerFollowUser", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, FollowRequest(request))
+}
+
+// handlerUnfollowUser - unfollows user_id, or withdraws my pending follow
+// request to them
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerUnfollowUser", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerUnfollowUser", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{
+			RequesterID: userID,
+			TargetID:    followeeID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "you don't follow this user", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "can't withdraw the follow request - handlerUnfollowUser", err)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerUnfollowUser", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't unfollow the user - handlerUnfollowUser", err)
+		return
+	}
+
+	err = qtx.UpdateFollowCounts(r.Context(), database.UpdateFollowCountsParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+		Delta:      -1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't update follow counts - handlerUnfollowUser", err)
+		return
+	}
+
+	err = qtx.DeleteTimelineEntriesByAuthor(r.Context(), database.DeleteTimelineEntriesByAuthorParams{
+		UserID:   userID,
+		AuthorID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't clean up the timeline - handlerUnfollowUser", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerUnfollowUser", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListFollowers - a page of the users following user_id, the most
+// recent follower first
+func (cfg *apiConfig) handlerListFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, page, ok := cfg.followListParams(w, r)
+	if !ok {
+		return
+	}
+
+	args := database.ListFollowersDescParams{
+		UserID:          userID,
+		ViewerID:        viewerID(r.Context()),
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []FollowUser{}
+	if page.Descending() {
+		rows, err := cfg.db.ListFollowersDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list followers - handlerListFollowers", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, FollowUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListFollowersAsc(r.Context(), database.ListFollowersAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list followers - handlerListFollowers", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, FollowUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, followUserCursor))
+}
+
+// handlerListFollowing - a page of the users user_id follows, the most
+// recently followed first
+func (cfg *apiConfig) handlerListFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, page, ok := cfg.followListParams(w, r)
+	if !ok {
+		return
+	}
+
+	args := database.ListFollowingDescParams{
+		UserID:          userID,
+		ViewerID:        viewerID(r.Context()),
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []FollowUser{}
+	if page.Descending() {
+		rows, err := cfg.db.ListFollowingDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list followed users - handlerListFollowing", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, FollowUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListFollowingAsc(r.Context(), database.ListFollowingAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list followed users - handlerListFollowing", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, FollowUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, followUserCursor))
+}
+
+// followListParams - the user and page of a followers or following list. The
+// lists of a private account are only shown to its followers, and neither are
+// those of a user who blocked me or whom I blocked.
+func (cfg *apiConfig) followListParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, pagination.Params, bool) {
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id", err)
+		return uuid.Nil, pagination.Params{}, false
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return uuid.Nil, pagination.Params{}, false
+	}
+
+	visible, err := cfg.db.AuthorVisible(r.Context(), database.AuthorVisibleParams{
+		AuthorID: userID,
+		ViewerID: viewerID(r.Context()),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check the user's privacy", err)
+		return uuid.Nil, pagination.Params{}, false
+	}
+	if !visible {
+		respondWithError(w, http.StatusForbidden, "you can't see the follows of this user", nil)
+		return uuid.Nil, pagination.Params{}, false
+	}
+
+	return userID, page, true
+}
+
+func followUserCursor(user FollowUser) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+}
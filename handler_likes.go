@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+// LikeUser - an entry of the list of who liked a post
+type LikeUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"liked_at"`
+}
+
+// LikedPost - an entry of the posts a user liked
+type LikedPost struct {
+	Post
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// handlerListPostLikers - a page of the users who liked a post, the most
+// recent like first
+func (cfg *apiConfig) handlerListPostLikers(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerListPostLikers", err)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	visible, err := cfg.db.PostVisible(r.Context(), database.PostVisibleParams{
+		PostID:   postID,
+		ViewerID: viewerID(r.Context()),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check the post's visibility - handlerListPostLikers", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerListPostLikers", nil)
+		return
+	}
+
+	args := database.ListPostLikersDescParams{
+		PostID:          postID,
+		ViewerID:        viewerID(r.Context()),
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []LikeUser{}
+	if page.Descending() {
+		rows, err := cfg.db.ListPostLikersDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list likes - handlerListPostLikers", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, LikeUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListPostLikersAsc(r.Context(), database.ListPostLikersAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list likes - handlerListPostLikers", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, LikeUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, func(user LikeUser) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}))
+}
+
+// handlerListLikedPosts - a page of the posts user_id liked, the most recent
+// like first. The likes of a private account are only shown to its followers,
+// and posts the viewer can't see are left out.
+func (cfg *apiConfig) handlerListLikedPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the user id - handlerListLikedPosts", err)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the user - handlerListLikedPosts", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the user - handlerListLikedPosts", err)
+		return
+	}
+
+	visible, err := cfg.db.AuthorVisible(r.Context(), database.AuthorVisibleParams{
+		AuthorID: userID,
+		ViewerID: viewerID(r.Context()),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check the user's privacy - handlerListLikedPosts", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusForbidden, "you can't see the likes of this user", nil)
+		return
+	}
+
+	args := database.ListLikedPostsDescParams{
+		UserID:          userID,
+		ViewerID:        viewerID(r.Context()),
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	var posts []database.Post
+	var likedAt []time.Time
+	if page.Descending() {
+		rows, err := cfg.db.ListLikedPostsDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list liked posts - handlerListLikedPosts", err)
+			return
+		}
+		for _, row := range rows {
+			posts = append(posts, row.Post)
+			likedAt = append(likedAt, row.LikedAt)
+		}
+	} else {
+		rows, err := cfg.db.ListLikedPostsAsc(r.Context(), database.ListLikedPostsAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list liked posts - handlerListLikedPosts", err)
+			return
+		}
+		for _, row := range rows {
+			posts = append(posts, row.Post)
+			likedAt = append(likedAt, row.LikedAt)
+		}
+	}
+
+	converted, err := cfg.postsWithOriginals(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original posts - handlerListLikedPosts", err)
+		return
+	}
+
+	response := make([]LikedPost, 0, len(converted))
+	for i, post := range converted {
+		response = append(response, LikedPost{Post: post, LikedAt: likedAt[i]})
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(response, page, func(post LikedPost) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: post.LikedAt, ID: post.ID}
+	}))
+}
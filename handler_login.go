@@ -1,23 +1,24 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/imhasandl/go-restapi/internal/auth"
 	"github.com/imhasandl/go-restapi/internal/database"
 )
 
 func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	type response struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -28,50 +29,143 @@ func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	user, lockedFor, err := cfg.checkPassword(r, params.Email, params.Password)
+	if lockedFor > 0 {
+		respondWithLoginLocked(w, lockedFor)
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't get user by email", err)
+		if errors.Is(err, errIncorrectPassword) {
+			respondWithError(w, http.StatusUnauthorized, "incorrect email or password", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't check password", err)
 		return
 	}
 
-	err = auth.CheckPasswordHash(params.Password, user.Password)
+	cfg.completeLogin(w, r, user, params.DeviceName)
+}
+
+var errIncorrectPassword = errors.New("incorrect email or password")
+
+// checkPassword - the first login factor, shared by every form that asks for
+// a password. While the email or IP is locked out it returns how long the
+// lock lasts instead of checking anything.
+func (cfg *apiConfig) checkPassword(r *http.Request, email, password string) (database.User, time.Duration, error) {
+	throttles := []loginThrottle{accountThrottle(email), ipThrottle(r)}
+	lockedFor, err := cfg.loginLockedFor(r.Context(), throttles...)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "wrong password", err)
-		return
+		return database.User{}, 0, err
+	}
+	if lockedFor > 0 {
+		return database.User{}, lockedFor, nil
 	}
 
-	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+	// Unknown emails and wrong passwords get the same answer in the same time
+	user, err := cfg.db.GetUserByEmail(r.Context(), email)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't create JWT", err)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, 0, err
+		}
+		auth.CheckPasswordDummy(cfg.passwordHasher, password)
+	} else if !hasPassword(user) {
+		// Accounts made through a login provider can't log in with a password
+		auth.CheckPasswordDummy(cfg.passwordHasher, password)
+		err = errors.New("user has no password")
+	} else {
+		err = auth.CheckPasswordHash(password, user.Password)
+	}
+	if err != nil {
+		if err := cfg.recordLoginFailure(r.Context(), throttles...); err != nil {
+			log.Printf("Can't record failed login: %s", err)
+		}
+		return database.User{}, 0, errIncorrectPassword
+	}
+
+	err = cfg.db.ResetLoginThrottle(r.Context(), accountThrottle(email).key)
+	if err != nil {
+		log.Printf("Can't reset login attempts: %s", err)
+	}
+
+	// Hashes made with an older algorithm or weaker parameters are upgraded
+	// while we still have the plain password
+	if cfg.passwordHasher.NeedsRehash(user.Password) {
+		cfg.rehashPassword(r.Context(), user.ID, password)
+	}
+
+	return user, 0, nil
+}
+
+// completeLogin - called once the first factor checked out. With MFA enabled
+// it only earns a challenge token, otherwise the user is logged in.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User, deviceName string) {
+	type mfaChallenge struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+
+	if user.TotpEnabledAt.Valid {
+		mfaToken, err := auth.MakeMFAToken(user.ID, cfg.jwtKeys, mfaTokenTTL)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't create mfa token", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, mfaChallenge{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
 		return
 	}
 
-	refreshToken, err := auth.MakeRefreshToken()
+	cfg.respondWithLogin(w, r, user, deviceName)
+}
+
+// respondWithLogin - starts a session for the user and responds with its tokens
+func (cfg *apiConfig) respondWithLogin(w http.ResponseWriter, r *http.Request, user database.User, deviceName string) {
+	type response struct {
+		Me
+		Token        string    `json:"token"`
+		RefreshToken string    `json:"refresh_token"`
+		SessionID    uuid.UUID `json:"session_id"`
+	}
+
+	session, refreshToken, err := cfg.createSession(r, user.ID, deviceName, grant{})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "can't refresh token", err)
+		respondWithError(w, http.StatusInternalServerError, "can't create session", err)
 		return
 	}
 
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
+	accessToken, err := auth.MakeJWT(auth.Claims{
 		UserID:    user.ID,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 60),
-	})
+		Role:      auth.Role(user.Role),
+		SessionID: session.ID,
+	}, cfg.jwtKeys, time.Hour)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't create refresh token", err)
+		respondWithError(w, http.StatusBadRequest, "can't create JWT", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusAccepted, response{
-		User: User{
-			ID:        user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email:     user.Email,
-			Username:  user.Username,
-			IsPremium: user.IsPremium,
-		},
+		Me:           databaseUserToMe(user),
 		Token:        accessToken,
 		RefreshToken: refreshToken,
+		SessionID:    session.ID,
+	})
+}
+
+func (cfg *apiConfig) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := cfg.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("Can't rehash password of user %s: %s", userID, err)
+		return
+	}
+
+	err = cfg.db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		Password: hashedPassword,
+		ID:       userID,
 	})
+	if err != nil {
+		log.Printf("Can't save rehashed password of user %s: %s", userID, err)
+	}
 }
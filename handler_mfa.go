@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+const (
+	totpIssuer         = "social-media-go"
+	mfaTokenTTL        = time.Minute * 5
+	recoveryCodesCount = 10
+)
+
+var errInvalidSecondFactor = errors.New("invalid mfa code")
+
+func (cfg *apiConfig) handlerEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Secret string `json:"secret"`
+		URI    string `json:"otpauth_uri"`
+	}
+
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't generate totp secret - handlerEnrollTOTP", err)
+		return
+	}
+
+	// Enrolling again before confirming replaces the unconfirmed secret
+	rows, err := cfg.db.SetTOTPSecret(r.Context(), database.SetTOTPSecretParams{
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+		ID:         user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't save totp secret - handlerEnrollTOTP", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusConflict, "totp is already enabled - handlerEnrollTOTP", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Secret: secret,
+		URI:    auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+func (cfg *apiConfig) handlerConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerConfirmTOTP", err)
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "totp is already enabled - handlerConfirmTOTP", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "enroll totp first - handlerConfirmTOTP", nil)
+		return
+	}
+
+	step, err := auth.ValidateTOTP(user.TotpSecret.String, params.Code, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid totp code - handlerConfirmTOTP", err)
+		return
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't generate recovery codes - handlerConfirmTOTP", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerConfirmTOTP", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.EnableTOTP(r.Context(), database.EnableTOTPParams{
+		TotpLastUsedStep: sql.NullInt64{Int64: step, Valid: true},
+		ID:               user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't enable totp - handlerConfirmTOTP", err)
+		return
+	}
+
+	err = qtx.DeleteRecoveryCodesByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't delete old recovery codes - handlerConfirmTOTP", err)
+		return
+	}
+
+	for _, code := range recoveryCodes {
+		err = qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+			ID:       uuid.New(),
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(code),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't save recovery code - handlerConfirmTOTP", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit totp - handlerConfirmTOTP", err)
+		return
+	}
+
+	// Recovery codes are only shown once, we keep nothing but their hashes
+	respondWithJSON(w, http.StatusOK, response{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (cfg *apiConfig) handlerDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerDisableTOTP", err)
+		return
+	}
+
+	if !user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusBadRequest, "totp is not enabled - handlerDisableTOTP", nil)
+		return
+	}
+
+	// A stolen access token mustn't be enough to guess the code and turn 2FA off
+	lockedFor, err := cfg.checkSecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if lockedFor > 0 {
+		respondWithLoginLocked(w, lockedFor)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid mfa code - handlerDisableTOTP", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerDisableTOTP", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.DisableTOTP(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't disable totp - handlerDisableTOTP", err)
+		return
+	}
+
+	err = qtx.DeleteRecoveryCodesByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't delete recovery codes - handlerDisableTOTP", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit - handlerDisableTOTP", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+		DeviceName   string `json:"device_name"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerLoginMFA", err)
+		return
+	}
+
+	userID, err := auth.ValidateMFAToken(params.MFAToken, cfg.jwtKeys)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid mfa token - handlerLoginMFA", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "can't get user - handlerLoginMFA", err)
+		return
+	}
+
+	lockedFor, err := cfg.checkSecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if lockedFor > 0 {
+		respondWithLoginLocked(w, lockedFor)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid mfa code - handlerLoginMFA", err)
+		return
+	}
+
+	cfg.respondWithLogin(w, r, user, params.DeviceName)
+}
+
+// checkSecondFactor - verifySecondFactor for logins and turning TOTP off,
+// where failed attempts are limited per user. While locked out it returns how long the lock lasts.
+func (cfg *apiConfig) checkSecondFactor(ctx context.Context, user database.User, code, recoveryCode string) (time.Duration, error) {
+	// Six digit codes are easy to guess without a limit on attempts
+	lockedFor, err := cfg.loginLockedFor(ctx, mfaThrottle(user.ID))
+	if err != nil {
+		return 0, err
+	}
+	if lockedFor > 0 {
+		return lockedFor, nil
+	}
+
+	err = cfg.verifySecondFactor(ctx, user, code, recoveryCode)
+	if err != nil {
+		if err := cfg.recordLoginFailure(ctx, mfaThrottle(user.ID)); err != nil {
+			log.Printf("Can't record failed mfa login: %s", err)
+		}
+		return 0, err
+	}
+
+	err = cfg.db.ResetLoginThrottle(ctx, mfaThrottle(user.ID).key)
+	if err != nil {
+		log.Printf("Can't reset mfa login attempts: %s", err)
+	}
+	return 0, nil
+}
+
+// verifySecondFactor - accepts either a TOTP code or an unused recovery code,
+// both can only be used once
+func (cfg *apiConfig) verifySecondFactor(ctx context.Context, user database.User, code, recoveryCode string) error {
+	if !user.TotpEnabledAt.Valid || !user.TotpSecret.Valid {
+		return errors.New("totp is not enabled")
+	}
+
+	if recoveryCode != "" {
+		rows, err := cfg.db.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(recoveryCode),
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return errInvalidSecondFactor
+		}
+		return nil
+	}
+
+	step, err := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if err != nil {
+		return errInvalidSecondFactor
+	}
+
+	rows, err := cfg.db.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		TotpLastUsedStep: sql.NullInt64{Int64: step, Valid: true},
+		ID:               user.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("totp code was already used")
+	}
+	return nil
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+const (
+	oauthCodeTTL        = 5 * time.Minute
+	oauthAccessTokenTTL = time.Hour
+)
+
+var errInvalidOAuthClient = errors.New("invalid client")
+
+// oauthError - error response of the OAuth endpoints (RFC 6749 section 5.2),
+// also sent back to the client's redirect uri by the authorization endpoint
+type oauthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *oauthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// authorizeRequest - a validated authorization request
+type authorizeRequest struct {
+	client        database.OauthClient
+	redirectURI   string
+	scopes        []auth.Scope
+	state         string
+	codeChallenge string
+}
+
+// handlerOAuthAuthorize - shows the consent page to the user
+func (cfg *apiConfig) handlerOAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	req, err := cfg.parseAuthorizeRequest(r.Context(), r.URL.Query())
+	if err != nil {
+		respondWithAuthorizeError(w, r, req, err)
+		return
+	}
+
+	renderOAuthPage(w, http.StatusOK, "consent", newConsentPage(req))
+}
+
+// handlerOAuthApprove - the consent form is posted here. The user logs in on
+// the form, since the API has no cookies to know who is looking at the page.
+func (cfg *apiConfig) handlerOAuthApprove(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		renderOAuthPage(w, http.StatusBadRequest, "error", "The form could not be read.")
+		return
+	}
+
+	req, err := cfg.parseAuthorizeRequest(r.Context(), r.PostForm)
+	if err != nil {
+		respondWithAuthorizeError(w, r, req, err)
+		return
+	}
+
+	if r.PostForm.Get("decision") != "allow" {
+		respondWithAuthorizeError(w, r, req, &oauthError{Code: "access_denied", Description: "the user denied the request"})
+		return
+	}
+
+	page := newConsentPage(req)
+	page.Email = r.PostForm.Get("email")
+
+	user, lockedFor, err := cfg.checkPassword(r, r.PostForm.Get("email"), r.PostForm.Get("password"))
+	if lockedFor > 0 {
+		page.Error = fmt.Sprintf("Too many failed attempts, try again in %s.", lockedFor.Round(time.Second))
+		renderOAuthPage(w, http.StatusTooManyRequests, "consent", page)
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, errIncorrectPassword) {
+			log.Printf("Can't check password on consent page: %s", err)
+		}
+		page.Error = "Incorrect email or password."
+		renderOAuthPage(w, http.StatusUnauthorized, "consent", page)
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		lockedFor, err := cfg.checkSecondFactor(r.Context(), user, r.PostForm.Get("code"), "")
+		if lockedFor > 0 {
+			page.Error = fmt.Sprintf("Too many failed attempts, try again in %s.", lockedFor.Round(time.Second))
+			renderOAuthPage(w, http.StatusTooManyRequests, "consent", page)
+			return
+		}
+		if err != nil {
+			page.Error = "Enter a valid code from your authenticator app."
+			renderOAuthPage(w, http.StatusUnauthorized, "consent", page)
+			return
+		}
+	}
+
+	err = cfg.db.DeleteExpiredAuthorizationCodes(r.Context())
+	if err != nil {
+		log.Printf("Can't delete expired authorization codes: %s", err)
+	}
+
+	code, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithAuthorizeError(w, r, req, &oauthError{Code: "server_error"})
+		return
+	}
+
+	scopeNames := grant{clientID: sql.NullString{String: req.client.ID, Valid: true}, scopes: req.scopes}.scopeNames()
+	_, err = cfg.db.CreateAuthorizationCode(r.Context(), database.CreateAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      req.client.ID,
+		UserID:        user.ID,
+		RedirectUri:   req.redirectURI,
+		Scopes:        scopeNames,
+		CodeChallenge: req.codeChallenge,
+		ExpiresAt:     time.Now().UTC().Add(oauthCodeTTL),
+	})
+	if err != nil {
+		log.Printf("Can't save authorization code: %s", err)
+		respondWithAuthorizeError(w, r, req, &oauthError{Code: "server_error"})
+		return
+	}
+
+	redirectToClient(w, r, req.redirectURI, url.Values{
+		"code":  {code},
+		"state": {req.state},
+	})
+}
+
+// parseAuthorizeRequest - as long as the returned request has no redirect
+// uri, errors must be shown to the user instead of being sent to the client
+func (cfg *apiConfig) parseAuthorizeRequest(ctx context.Context, values url.Values) (authorizeRequest, error) {
+	req := authorizeRequest{}
+
+	client, err := cfg.db.GetOAuthClient(ctx, values.Get("client_id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return req, errors.New("unknown client")
+		}
+		return req, err
+	}
+	req.client = client
+
+	redirectURI := values.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectUris) == 1 {
+		redirectURI = client.RedirectUris[0]
+	}
+	registered := false
+	for _, uri := range client.RedirectUris {
+		if uri == redirectURI {
+			registered = true
+		}
+	}
+	if !registered {
+		return req, errors.New("redirect uri isn't registered for this client")
+	}
+	req.redirectURI = redirectURI
+	req.state = values.Get("state")
+
+	if values.Get("response_type") != "code" {
+		return req, &oauthError{Code: "unsupported_response_type", Description: "only the code response type is supported"}
+	}
+	if values.Get("code_challenge_method") != "S256" || values.Get("code_challenge") == "" {
+		return req, &oauthError{Code: "invalid_request", Description: "pkce with the S256 method is required"}
+	}
+	req.codeChallenge = values.Get("code_challenge")
+
+	scopes, err := auth.ParseScopeParam(values.Get("scope"))
+	if err != nil {
+		return req, &oauthError{Code: "invalid_scope", Description: err.Error()}
+	}
+	req.scopes = scopes
+
+	return req, nil
+}
+
+func respondWithAuthorizeError(w http.ResponseWriter, r *http.Request, req authorizeRequest, err error) {
+	var oauthErr *oauthError
+	if req.redirectURI == "" || !errors.As(err, &oauthErr) {
+		renderOAuthPage(w, http.StatusBadRequest, "error", fmt.Sprintf("The app sent an invalid request: %s.", err))
+		return
+	}
+
+	params := url.Values{
+		"error": {oauthErr.Code},
+		"state": {req.state},
+	}
+	if oauthErr.Description != "" {
+		params.Set("error_description", oauthErr.Description)
+	}
+	redirectToClient(w, r, req.redirectURI, params)
+}
+
+func redirectToClient(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		renderOAuthPage(w, http.StatusBadRequest, "error", "The app has an invalid redirect uri.")
+		return
+	}
+
+	query := target.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	target.RawQuery = query.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// handlerOAuthToken - the token endpoint, supports the authorization_code and
+// refresh_token grants
+func (cfg *apiConfig) handlerOAuthToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "can't parse the form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(r)
+	if err != nil {
+		respondWithClientAuthError(w, err)
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		cfg.exchangeAuthorizationCode(w, r, client)
+	case "refresh_token":
+		cfg.exchangeOAuthRefreshToken(w, r, client)
+	default:
+		respondWithOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+}
+
+func (cfg *apiConfig) exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, client database.OauthClient) {
+	codeHash := auth.HashToken(r.PostForm.Get("code"))
+
+	stored, err := cfg.db.ConsumeAuthorizationCode(r.Context(), codeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.revokeReplayedCode(r.Context(), codeHash)
+			respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "authorization code is invalid, expired or already used")
+			return
+		}
+		respondWithOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	if stored.ClientID != client.ID || stored.RedirectUri != r.PostForm.Get("redirect_uri") {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "authorization code was issued to another client or redirect uri")
+		return
+	}
+	if !auth.VerifyCodeChallenge(r.PostForm.Get("code_verifier"), stored.CodeChallenge) {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "code verifier doesn't match the code challenge")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), stored.UserID)
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "user no longer exists")
+		return
+	}
+
+	g := grant{
+		clientID: sql.NullString{String: client.ID, Valid: true},
+		scopes:   scopesFromNames(stored.Scopes),
+	}
+	session, refreshToken, err := cfg.createSession(r, user.ID, client.Name, g)
+	if err != nil {
+		respondWithOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	err = cfg.db.SetAuthorizationCodeSession(r.Context(), database.SetAuthorizationCodeSessionParams{
+		CodeHash:  codeHash,
+		SessionID: uuid.NullUUID{UUID: session.ID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Can't link authorization code to session %s: %s", session.ID, err)
+	}
+
+	cfg.respondWithOAuthTokens(w, user, session.ID, refreshToken, g)
+}
+
+// revokeReplayedCode - a code that is presented twice was intercepted, so
+// the grant made with it is revoked (RFC 6749 section 4.1.2)
+func (cfg *apiConfig) revokeReplayedCode(ctx context.Context, codeHash string) {
+	stored, err := cfg.db.GetAuthorizationCode(ctx, codeHash)
+	if err != nil || !stored.UsedAt.Valid || !stored.SessionID.Valid {
+		return
+	}
+
+	log.Printf("Authorization code reuse detected for client %s, revoking session %s", stored.ClientID, stored.SessionID.UUID)
+	err = cfg.revokeSession(ctx, stored.UserID, stored.SessionID.UUID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Can't revoke session of replayed authorization code: %s", err)
+	}
+}
+
+func (cfg *apiConfig) exchangeOAuthRefreshToken(w http.ResponseWriter, r *http.Request, client database.OauthClient) {
+	clientID := sql.NullString{String: client.ID, Valid: true}
+
+	user, rotated, err := cfg.rotateRefreshToken(r.Context(), r.PostForm.Get("refresh_token"), clientID)
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token is invalid, expired or revoked")
+		return
+	}
+
+	cfg.respondWithOAuthTokens(w, user, rotated.FamilyID, rotated.Token, grant{
+		clientID: rotated.ClientID,
+		scopes:   scopesFromNames(rotated.Scopes),
+	})
+}
+
+func (cfg *apiConfig) respondWithOAuthTokens(w http.ResponseWriter, user database.User, sessionID uuid.UUID, refreshToken string, g grant) {
+	type response struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}
+
+	accessToken, err := auth.MakeJWT(auth.Claims{
+		UserID:    user.ID,
+		Role:      auth.Role(user.Role),
+		SessionID: sessionID,
+		ClientID:  g.clientID.String,
+		Scopes:    g.scopes,
+	}, cfg.jwtKeys, oauthAccessTokenTTL)
+	if err != nil {
+		respondWithOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, response{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(oauthAccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        auth.FormatScopes(g.scopes),
+	})
+}
+
+// handlerOAuthIntrospect - token introspection (RFC 7662). Clients can only
+// introspect their own tokens, any other token is reported as inactive.
+func (cfg *apiConfig) handlerOAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		Sub       string `json:"sub,omitempty"`
+		Exp       int64  `json:"exp,omitempty"`
+		Iat       int64  `json:"iat,omitempty"`
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "can't parse the form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(r)
+	if err != nil {
+		respondWithClientAuthError(w, err)
+		return
+	}
+	if !client.SecretHash.Valid {
+		respondWithOAuthError(w, http.StatusUnauthorized, "invalid_client", "public clients can't introspect tokens")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+
+	claims, err := auth.ValidateJWT(token, cfg.jwtKeys, cfg.sessionChecker(r.Context()))
+	if err == nil && claims.ClientID == client.ID {
+		respondWithJSON(w, http.StatusOK, response{
+			Active:    true,
+			Scope:     auth.FormatScopes(claims.Scopes),
+			ClientID:  claims.ClientID,
+			TokenType: "access_token",
+			Sub:       claims.UserID.String(),
+			Exp:       claims.ExpiresAt.Unix(),
+			Iat:       claims.IssuedAt.Unix(),
+		})
+		return
+	}
+
+	stored, err := cfg.db.GetRefreshToken(r.Context(), token)
+	if err == nil && stored.ClientID.String == client.ID && !stored.RevokedAt.Valid && time.Now().UTC().Before(stored.ExpiresAt) {
+		respondWithJSON(w, http.StatusOK, response{
+			Active:    true,
+			Scope:     auth.FormatScopes(scopesFromNames(stored.Scopes)),
+			ClientID:  client.ID,
+			TokenType: "refresh_token",
+			Sub:       stored.UserID.String(),
+			Exp:       stored.ExpiresAt.Unix(),
+			Iat:       stored.CreatedAt.Unix(),
+		})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{Active: false})
+}
+
+// handlerOAuthRevoke - token revocation (RFC 7009). Revoking either token of
+// a grant ends the whole grant. Unknown tokens are not an error.
+func (cfg *apiConfig) handlerOAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "can't parse the form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(r)
+	if err != nil {
+		respondWithClientAuthError(w, err)
+		return
+	}
+	clientID := sql.NullString{String: client.ID, Valid: true}
+	token := r.PostForm.Get("token")
+
+	err = cfg.revokeRefreshToken(r.Context(), token, clientID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, errRefreshTokenOtherClient) {
+		respondWithOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		claims, err := auth.ValidateJWT(token, cfg.jwtKeys, nil)
+		if err == nil && claims.ClientID == client.ID {
+			err = cfg.revokeSession(r.Context(), claims.UserID, claims.SessionID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				respondWithOAuthError(w, http.StatusInternalServerError, "server_error", "")
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticateOAuthClient - confidential clients authenticate with HTTP Basic
+// or client_id and client_secret in the form, public clients only send client_id
+func (cfg *apiConfig) authenticateOAuthClient(r *http.Request) (database.OauthClient, error) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		var err error
+		clientID, err = url.QueryUnescape(clientID)
+		if err != nil {
+			return database.OauthClient{}, errInvalidOAuthClient
+		}
+		clientSecret, err = url.QueryUnescape(clientSecret)
+		if err != nil {
+			return database.OauthClient{}, errInvalidOAuthClient
+		}
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	client, err := cfg.db.GetOAuthClient(r.Context(), clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.OauthClient{}, errInvalidOAuthClient
+		}
+		return database.OauthClient{}, err
+	}
+
+	if client.SecretHash.Valid {
+		secretHash := auth.HashToken(clientSecret)
+		if subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.SecretHash.String)) != 1 {
+			return database.OauthClient{}, errInvalidOAuthClient
+		}
+	}
+
+	return client, nil
+}
+
+func respondWithClientAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInvalidOAuthClient) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		respondWithOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	log.Printf("Can't authenticate oauth client: %s", err)
+	respondWithOAuthError(w, http.StatusInternalServerError, "server_error", "")
+}
+
+func respondWithOAuthError(w http.ResponseWriter, code int, errorCode, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, code, oauthError{
+		Code:        errorCode,
+		Description: description,
+	})
+}
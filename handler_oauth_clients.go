@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+type OAuthClient struct {
+	ClientID     string    `json:"client_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+}
+
+func databaseOAuthClientToOAuthClient(client database.OauthClient) OAuthClient {
+	return OAuthClient{
+		ClientID:     client.ID,
+		CreatedAt:    client.CreatedAt,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Public:       !client.SecretHash.Valid,
+	}
+}
+
+// handlerCreateOAuthClient - registers a third-party app. Public clients
+// (mobile and single page apps) can't keep a secret and get none.
+func (cfg *apiConfig) handlerCreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Public       bool     `json:"public"`
+	}
+	type response struct {
+		OAuthClient
+		ClientSecret string `json:"client_secret,omitempty"`
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerCreateOAuthClient", err)
+		return
+	}
+
+	if params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "name is required", nil)
+		return
+	}
+	if len(params.RedirectURIs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "at least one redirect uri is required", nil)
+		return
+	}
+	for _, uri := range params.RedirectURIs {
+		err = auth.ValidateRedirectURI(uri)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	clientID, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't generate client id - handlerCreateOAuthClient", err)
+		return
+	}
+	clientID = clientID[:32]
+
+	clientSecret := ""
+	secretHash := sql.NullString{}
+	if !params.Public {
+		clientSecret, err = auth.MakeRefreshToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't generate client secret - handlerCreateOAuthClient", err)
+			return
+		}
+		secretHash = sql.NullString{String: auth.HashToken(clientSecret), Valid: true}
+	}
+
+	client, err := cfg.db.CreateOAuthClient(r.Context(), database.CreateOAuthClientParams{
+		ID:           clientID,
+		UserID:       userID,
+		Name:         params.Name,
+		RedirectUris: params.RedirectURIs,
+		SecretHash:   secretHash,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't save client - handlerCreateOAuthClient", err)
+		return
+	}
+
+	// The plain secret is only ever shown here, we keep just its hash
+	respondWithJSON(w, http.StatusCreated, response{
+		OAuthClient:  databaseOAuthClientToOAuthClient(client),
+		ClientSecret: clientSecret,
+	})
+}
+
+func (cfg *apiConfig) handlerListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	clients, err := cfg.db.ListOAuthClientsByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list clients - handlerListOAuthClients", err)
+		return
+	}
+
+	response := make([]OAuthClient, 0, len(clients))
+	for _, client := range clients {
+		response = append(response, databaseOAuthClientToOAuthClient(client))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerDeleteOAuthClient - deleting a client also ends every grant users gave it
+func (cfg *apiConfig) handlerDeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	_, err := cfg.db.DeleteOAuthClient(r.Context(), database.DeleteOAuthClientParams{
+		ID:     r.PathValue("client_id"),
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find client - handlerDeleteOAuthClient", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't delete client - handlerDeleteOAuthClient", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/oidc"
+)
+
+const oidcLoginStateTTL = 10 * time.Minute
+
+// oidcBindingCookie - holds a secret only the browser that started a login
+// knows. The callback checks it, so a callback URL sent to someone else
+// doesn't finish the login in their browser.
+const oidcBindingCookie = "oidc_binding"
+
+var (
+	errIdentityEmailNotVerified = errors.New("the provider didn't confirm the email is verified")
+	errIdentityEmailTaken       = errors.New("an account with this email exists, log in and connect the provider from your account")
+	errIdentityLinkedElsewhere  = errors.New("this identity is already connected to another account")
+	errIdentityProviderLinked   = errors.New("a different identity of this provider is already connected")
+)
+
+type Identity struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+}
+
+func databaseIdentityToIdentity(identity database.UserIdentity) Identity {
+	return Identity{
+		ID:        identity.ID,
+		CreatedAt: identity.CreatedAt,
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+	}
+}
+
+// handlerOIDCLogin - sends the browser to the provider's login page
+func (cfg *apiConfig) handlerOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.oidcProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "unknown login provider", nil)
+		return
+	}
+
+	authURL, err := cfg.startOIDCLogin(w, r, provider, uuid.NullUUID{})
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "can't start login with provider - handlerOIDCLogin", err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handlerConnectIdentity - like handlerOIDCLogin, but the identity is
+// connected to the logged in user. The client opens the returned URL itself,
+// since a redirect can't carry the Authorization header, in the browser that
+// made this request and got the binding cookie.
+func (cfg *apiConfig) handlerConnectIdentity(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	provider, ok := cfg.oidcProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "unknown login provider", nil)
+		return
+	}
+
+	authURL, err := cfg.startOIDCLogin(w, r, provider, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "can't start login with provider - handlerConnectIdentity", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		AuthorizationURL: authURL,
+	})
+}
+
+// handlerOIDCCallback - the provider redirects here after the login. Logs the
+// user in, or finishes connecting the identity when the flow was started by
+// handlerConnectIdentity.
+func (cfg *apiConfig) handlerOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.oidcProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "unknown login provider", nil)
+		return
+	}
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("login with provider failed: %s", providerErr), nil)
+		return
+	}
+
+	loginState, err := cfg.db.ConsumeOIDCLoginState(r.Context(), auth.HashToken(query.Get("state")))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "login attempt is unknown or expired - handlerOIDCCallback", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get login attempt - handlerOIDCCallback", err)
+		return
+	}
+	if loginState.Provider != provider.Name() {
+		respondWithError(w, http.StatusBadRequest, "login attempt was started with another provider - handlerOIDCCallback", nil)
+		return
+	}
+
+	binding, err := r.Cookie(oidcBindingCookie)
+	clearOIDCBindingCookie(w, r)
+	if err != nil || subtle.ConstantTimeCompare([]byte(auth.HashToken(binding.Value)), []byte(loginState.BindingHash)) != 1 {
+		respondWithError(w, http.StatusForbidden, "login attempt was started in another browser - handlerOIDCCallback", err)
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), query.Get("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "can't verify login with provider - handlerOIDCCallback", err)
+		return
+	}
+
+	if loginState.UserID.Valid {
+		userIdentity, err := cfg.connectIdentity(r.Context(), loginState.UserID.UUID, provider.Name(), identity)
+		if err != nil {
+			respondWithIdentityError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, databaseIdentityToIdentity(userIdentity))
+		return
+	}
+
+	user, err := cfg.userForIdentity(r.Context(), provider.Name(), identity)
+	if err != nil {
+		respondWithIdentityError(w, err)
+		return
+	}
+
+	cfg.completeLogin(w, r, user, "")
+}
+
+func (cfg *apiConfig) handlerListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	identities, err := cfg.db.ListUserIdentities(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list identities - handlerListIdentities", err)
+		return
+	}
+
+	response := make([]Identity, 0, len(identities))
+	for _, identity := range identities {
+		response = append(response, databaseIdentityToIdentity(identity))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerDisconnectIdentity(w http.ResponseWriter, r *http.Request) {
+	identityIDString := r.PathValue("identity_id")
+	identityID, err := uuid.Parse(identityIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse identity id - handlerDisconnectIdentity", err)
+		return
+	}
+
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerDisconnectIdentity", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.DeleteUserIdentity(r.Context(), database.DeleteUserIdentityParams{
+		ID:     identityID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find identity - handlerDisconnectIdentity", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't disconnect identity - handlerDisconnectIdentity", err)
+		return
+	}
+
+	// Users who signed up through a provider have no password, they must keep a way to log in
+	remaining, err := qtx.CountUserIdentities(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't count identities - handlerDisconnectIdentity", err)
+		return
+	}
+	if remaining == 0 && !hasPassword(user) {
+		respondWithError(w, http.StatusConflict, "set a password before disconnecting your last login provider", nil)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit - handlerDisconnectIdentity", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startOIDCLogin - remembers state, nonce and PKCE verifier of the login
+// attempt, binds it to the browser with a cookie and returns the provider URL
+// to send the user to
+func (cfg *apiConfig) startOIDCLogin(w http.ResponseWriter, r *http.Request, provider *oidc.Client, userID uuid.NullUUID) (string, error) {
+	ctx := r.Context()
+
+	state, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+	binding, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", err
+	}
+
+	err = cfg.db.DeleteExpiredOIDCLoginStates(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = cfg.db.CreateOIDCLoginState(ctx, database.CreateOIDCLoginStateParams{
+		StateHash:    auth.HashToken(state),
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserID:       userID,
+		ExpiresAt:    time.Now().UTC().Add(oidcLoginStateTTL),
+		BindingHash:  auth.HashToken(binding),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    binding,
+		Path:     "/api/auth/oidc/",
+		MaxAge:   int(oidcLoginStateTTL.Seconds()),
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return authURL, nil
+}
+
+func clearOIDCBindingCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Path:     "/api/auth/oidc/",
+		MaxAge:   -1,
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// userForIdentity - finds the user the identity belongs to. Unknown
+// identities are linked to the account with the same verified email, or get
+// a new account.
+func (cfg *apiConfig) userForIdentity(ctx context.Context, provider string, identity oidc.Identity) (database.User, error) {
+	userIdentity, err := cfg.db.GetUserIdentity(ctx, database.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+	})
+	if err == nil {
+		return cfg.db.GetUserByID(ctx, userIdentity.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return database.User{}, errIdentityEmailNotVerified
+	}
+
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err := qtx.GetUserByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		// Linking to an unverified account would hand the login to whoever
+		// registered the email first
+		if !user.EmailVerifiedAt.Valid {
+			return database.User{}, errIdentityEmailTaken
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = createUserForIdentity(ctx, qtx, identity)
+		if err != nil {
+			return database.User{}, err
+		}
+	default:
+		return database.User{}, err
+	}
+
+	_, err = qtx.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return user, tx.Commit()
+}
+
+// createUserForIdentity - accounts made through a provider start verified and
+// without a password, one can be set with the password reset
+func createUserForIdentity(ctx context.Context, qtx *database.Queries, identity oidc.Identity) (database.User, error) {
+	suffix, err := auth.MakeRefreshToken()
+	if err != nil {
+		return database.User{}, err
+	}
+	username, _, _ := strings.Cut(identity.Email, "@")
+
+	user, err := qtx.CreateUser(ctx, database.CreateUserParams{
+		ID:       uuid.New(),
+		Email:    identity.Email,
+		Username: username + "_" + suffix[:6],
+		Password: "",
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return qtx.ConfirmUserEmail(ctx, database.ConfirmUserEmailParams{
+		Email: identity.Email,
+		ID:    user.ID,
+	})
+}
+
+func (cfg *apiConfig) connectIdentity(ctx context.Context, userID uuid.UUID, provider string, identity oidc.Identity) (database.UserIdentity, error) {
+	existing, err := cfg.db.GetUserIdentity(ctx, database.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+	})
+	if err == nil {
+		if existing.UserID != userID {
+			return database.UserIdentity{}, errIdentityLinkedElsewhere
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.UserIdentity{}, err
+	}
+
+	userIdentity, err := cfg.db.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return database.UserIdentity{}, errIdentityProviderLinked
+		}
+		return database.UserIdentity{}, err
+	}
+	return userIdentity, nil
+}
+
+func respondWithIdentityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errIdentityEmailNotVerified):
+		respondWithError(w, http.StatusForbidden, err.Error(), err)
+	case errors.Is(err, errIdentityEmailTaken),
+		errors.Is(err, errIdentityLinkedElsewhere),
+		errors.Is(err, errIdentityProviderLinked):
+		respondWithError(w, http.StatusConflict, err.Error(), err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "can't log in with provider", err)
+	}
+}
+
+// hasPassword - accounts created through a login provider have none
+func hasPassword(user database.User) bool {
+	return user.Password != ""
+}
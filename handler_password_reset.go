@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/mailer"
+)
+
+const passwordResetTokenTTL = time.Hour
+
+func (cfg *apiConfig) handlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerForgotPassword", err)
+		return
+	}
+
+	throttles := passwordResetThrottles(params.Email, r)
+	lockedFor, err := cfg.loginLockedFor(r.Context(), throttles...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check reset attempts - handlerForgotPassword", err)
+		return
+	}
+	if lockedFor > 0 {
+		respondWithLocked(w, lockedFor, "too many password reset requests, try again later")
+		return
+	}
+	err = cfg.recordLoginFailure(r.Context(), throttles...)
+	if err != nil {
+		log.Printf("Can't record password reset request: %s", err)
+	}
+
+	// The response is the same whether the email exists or not, and it doesn't
+	// wait for the lookup or the mail, so neither the answer nor its timing
+	// tells who has an account
+	go cfg.sendPasswordReset(context.WithoutCancel(r.Context()), params.Email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset - replaces the reset tokens of the user with the email
+// by a new one and mails it to them. Unknown emails are ignored, failures are
+// only logged.
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, email string) {
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Can't get user for password reset: %s", err)
+		}
+		return
+	}
+
+	err = cfg.mailPasswordReset(ctx, user)
+	if err != nil {
+		log.Printf("Can't send password reset to user %s: %s", user.ID, err)
+	}
+}
+
+func (cfg *apiConfig) mailPasswordReset(ctx context.Context, user database.User) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	// Only the latest reset token of a user can be used
+	err = cfg.db.InvalidatePasswordResetTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = cfg.db.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password of your account.\n\nYour reset token is: %s\n\nIt can be used once and expires in %s. If it wasn't you, you can ignore this email.",
+			token,
+			passwordResetTokenTTL,
+		),
+	})
+}
+
+func (cfg *apiConfig) handlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerResetPassword", err)
+		return
+	}
+
+	err = cfg.passwordPolicy.Validate(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't hash the password - handlerResetPassword", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerResetPassword", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	resetToken, err := qtx.ConsumePasswordResetToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "reset token is invalid or expired - handlerResetPassword", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't use reset token - handlerResetPassword", err)
+		return
+	}
+
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		Password: hashedPassword,
+		ID:       resetToken.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't update password - handlerResetPassword", err)
+		return
+	}
+
+	// Whoever knew the old password is logged out everywhere
+	err = qtx.RevokeAllSessionsForUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't revoke sessions - handlerResetPassword", err)
+		return
+	}
+
+	err = qtx.RevokeRefreshTokensByUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't revoke refresh tokens - handlerResetPassword", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit password reset - handlerResetPassword", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
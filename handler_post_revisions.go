@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+// PostRevision - the body a post had before the edit made at CreatedAt
+type PostRevision struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	PostID    uuid.UUID  `json:"post_id"`
+	EditedBy  *uuid.UUID `json:"edited_by"`
+	Body      string     `json:"body"`
+}
+
+func databasePostRevisionToPostRevision(revision database.PostRevision) PostRevision {
+	var editedBy *uuid.UUID
+	if revision.EditedBy.Valid {
+		editedBy = &revision.EditedBy.UUID
+	}
+
+	return PostRevision{
+		ID:        revision.ID,
+		CreatedAt: revision.CreatedAt,
+		PostID:    revision.PostID,
+		EditedBy:  editedBy,
+		Body:      revision.Body,
+	}
+}
+
+// handlerListPostRevisions - the edit history of a post, newest first
+func (cfg *apiConfig) handlerListPostRevisions(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerListPostRevisions", err)
+		return
+	}
+
+	post, err := cfg.db.GetPostByID(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerListPostRevisions", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerListPostRevisions", err)
+		return
+	}
+
+	visible, err := cfg.canSeePost(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check blocks - handlerListPostRevisions", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerListPostRevisions", nil)
+		return
+	}
+
+	revisions, err := cfg.db.ListPostRevisions(r.Context(), postID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list revisions - handlerListPostRevisions", err)
+		return
+	}
+
+	response := make([]PostRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		response = append(response, databasePostRevisionToPostRevision(revision))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -1,22 +1,194 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/imhasandl/go-restapi/internal/auth"
 	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+	"github.com/imhasandl/go-restapi/internal/visibility"
 )
 
 type Post struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	Body      string    `json:"body"`
-	Likes     int32     `json:"likes"`
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Body        string     `json:"body"`
+	Likes       int32      `json:"likes"`
+	Edited      bool       `json:"edited"`
+	EditedAt    *time.Time `json:"edited_at"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	RootID      *uuid.UUID `json:"root_id"`
+	ReplyCount  int32      `json:"reply_count"`
+	RepostOfID  *uuid.UUID `json:"repost_of_id"`
+	QuoteOfID   *uuid.UUID `json:"quote_of_id"`
+	RepostCount int32      `json:"repost_count"`
+	Visibility  string     `json:"visibility,omitempty"`
+	Deleted     bool       `json:"deleted"`
+	// Reactions - counts by reaction name, see handlerReactToPost
+	Reactions map[string]int32 `json:"reactions"`
+	// LikedByMe - only set for a logged in viewer, see postsWithOriginals
+	LikedByMe *bool `json:"liked_by_me,omitempty"`
+
+	// RepostOf and QuoteOf embed the original post, see postsWithOriginals
+	RepostOf *Post `json:"repost_of,omitempty"`
+	QuoteOf  *Post `json:"quote_of,omitempty"`
+}
+
+// databasePostToPost - a deleted post that still has replies is kept as a
+// tombstone, it only shows where it was in the thread
+func databasePostToPost(post database.Post) Post {
+	var editedAt *time.Time
+	if post.EditedAt.Valid {
+		editedAt = &post.EditedAt.Time
+	}
+	var parentID, rootID, repostOfID, quoteOfID *uuid.UUID
+	if post.ParentID.Valid {
+		parentID = &post.ParentID.UUID
+	}
+	if post.RootID.Valid {
+		rootID = &post.RootID.UUID
+	}
+	if post.RepostOfID.Valid {
+		repostOfID = &post.RepostOfID.UUID
+	}
+	if post.QuoteOfID.Valid {
+		quoteOfID = &post.QuoteOfID.UUID
+	}
+
+	// reaction_counts is a JSON object maintained by AddPostReactionCount, so
+	// it always decodes
+	reactions := map[string]int32{}
+	_ = json.Unmarshal(post.ReactionCounts, &reactions)
+
+	if post.DeletedAt.Valid {
+		return Post{
+			ID:         post.ID,
+			CreatedAt:  post.CreatedAt,
+			UpdatedAt:  post.UpdatedAt,
+			ParentID:   parentID,
+			RootID:     rootID,
+			ReplyCount: post.ReplyCount,
+			Deleted:    true,
+		}
+	}
+
+	return Post{
+		ID:          post.ID,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		UserID:      post.UserID,
+		Body:        post.Body,
+		Likes:       post.Likes,
+		Edited:      post.EditedAt.Valid,
+		EditedAt:    editedAt,
+		ParentID:    parentID,
+		RootID:      rootID,
+		ReplyCount:  post.ReplyCount,
+		RepostOfID:  repostOfID,
+		QuoteOfID:   quoteOfID,
+		RepostCount: post.RepostCount,
+		Visibility:  post.Visibility,
+		Reactions:   reactions,
+	}
+}
+
+// postsWithOriginals - converts posts and embeds the posts they repost or
+// quote. Embedded posts don't embed their own originals. For a logged in
+// viewer every post tells if they liked it.
+func (cfg *apiConfig) postsWithOriginals(ctx context.Context, posts []database.Post) ([]Post, error) {
+	ids := []uuid.UUID{}
+	for _, post := range posts {
+		if post.RepostOfID.Valid {
+			ids = append(ids, post.RepostOfID.UUID)
+		}
+		if post.QuoteOfID.Valid {
+			ids = append(ids, post.QuoteOfID.UUID)
+		}
+	}
+
+	likedIDs := make([]uuid.UUID, 0, len(posts)+len(ids))
+	for _, post := range posts {
+		likedIDs = append(likedIDs, post.ID)
+	}
+	likedIDs = append(likedIDs, ids...)
+	convert, err := cfg.postConverter(ctx, likedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	originals := map[uuid.UUID]Post{}
+	if len(ids) > 0 {
+		// Originals of users blocked by or blocking the viewer aren't embedded
+		rows, err := cfg.db.GetPostsByIDs(ctx, database.GetPostsByIDsParams{
+			Ids:      ids,
+			ViewerID: viewerID(ctx),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			originals[row.ID] = convert(row)
+		}
+	}
+
+	response := make([]Post, 0, len(posts))
+	for _, post := range posts {
+		converted := convert(post)
+		if original, ok := originals[post.RepostOfID.UUID]; ok && post.RepostOfID.Valid {
+			converted.RepostOf = &original
+		}
+		if original, ok := originals[post.QuoteOfID.UUID]; ok && post.QuoteOfID.Valid {
+			converted.QuoteOf = &original
+		}
+		response = append(response, converted)
+	}
+	return response, nil
+}
+
+// postConverter - databasePostToPost that also fills in liked_by_me for the
+// logged in viewer, looked up once for all of postIDs
+func (cfg *apiConfig) postConverter(ctx context.Context, postIDs []uuid.UUID) (func(database.Post) Post, error) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok || len(postIDs) == 0 {
+		return databasePostToPost, nil
+	}
+
+	likedIDs, err := cfg.db.ListLikedPostIDs(ctx, database.ListLikedPostIDsParams{
+		UserID:  userID,
+		PostIds: postIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+
+	return func(post database.Post) Post {
+		converted := databasePostToPost(post)
+		if !converted.Deleted {
+			likedByMe := liked[post.ID]
+			converted.LikedByMe = &likedByMe
+		}
+		return converted
+	}, nil
+}
+
+func (cfg *apiConfig) postWithOriginals(ctx context.Context, post database.Post) (Post, error) {
+	posts, err := cfg.postsWithOriginals(ctx, []database.Post{post})
+	if err != nil {
+		return Post{}, err
+	}
+	return posts[0], nil
 }
 
 type PostsLike struct {
@@ -26,64 +198,185 @@ type PostsLike struct {
 	CreatedAt time.Time
 }
 
+// handlerCreatePost - creates a post, a reply when parent_id is set and a
+// quote post when quote_of_id is set. The users mentioned in the body are
+// kept for the mentioned visibility.
 func (cfg *apiConfig) handlerCreatePost(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body       string     `json:"body"`
+		ParentID   *uuid.UUID `json:"parent_id"`
+		QuoteOfID  *uuid.UUID `json:"quote_of_id"`
+		Visibility string     `json:"visibility"`
 	}
 	type responce struct {
 		Post
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid header bearer", err)
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't validate JWT", err)
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "verify your email before posting", nil)
 		return
 	}
 
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't decode the body", err)
 		return
 	}
 
-	post, err := cfg.db.CreatePost(r.Context(), database.CreatePostParams{
-		ID:     uuid.New(),
-		UserID: userID,
-		Body:   params.Body,
-	})
+	level, err := visibility.Parse(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	createParams := database.CreatePostParams{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Body:       params.Body,
+		Visibility: string(level),
+	}
+
+	if params.QuoteOfID != nil {
+		quoted, err := originalPost(r.Context(), cfg.db, *params.QuoteOfID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "can't find the post to quote", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "can't get the post to quote", err)
+			return
+		}
+		visible, err := cfg.canSeePost(r.Context(), quoted)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't check blocks", err)
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusNotFound, "can't find the post to quote", nil)
+			return
+		}
+		if !visibility.Level(quoted.Visibility).Shareable() {
+			respondWithError(w, http.StatusBadRequest, "only public and unlisted posts can be quoted", nil)
+			return
+		}
+		createParams.QuoteOfID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	var post database.Post
+	if params.ParentID != nil {
+		post, err = cfg.createReply(r.Context(), qtx, createParams, *params.ParentID)
+	} else {
+		post, err = qtx.CreatePost(r.Context(), createParams)
+	}
+	if err != nil {
+		if errors.Is(err, errParentNotFound) {
+			respondWithError(w, http.StatusNotFound, "can't find the post to reply to", err)
+			return
+		}
 		respondWithError(w, http.StatusBadRequest, "can't post it", err)
 		return
 	}
 
+	err = qtx.CreatePostMentions(r.Context(), database.CreatePostMentionsParams{
+		PostID:    post.ID,
+		Usernames: visibility.Mentions(post.Body),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't save the mentions", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction", err)
+		return
+	}
+
+	cfg.fanOutPost(r.Context(), user, post)
+
+	response, err := cfg.postWithOriginals(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the quoted post", err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, responce{
-		Post: Post{
-			ID:        post.ID,
-			CreatedAt: post.CreatedAt,
-			UpdatedAt: post.UpdatedAt,
-			UserID:    post.UserID,
-			Body:      post.Body,
-			Likes:     post.Likes,
-		},
+		Post: response,
 	})
 }
 
+// handlerListPosts - a page of posts, filtered by author_id and a since/until
+// range on created_at
 func (cfg *apiConfig) handlerListPosts(w http.ResponseWriter, r *http.Request) {
-	posts, err := cfg.db.GetPosts(r.Context())
+	page, err := pagination.ParseParams(r.URL.Query())
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't list the posts", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	authorID, err := queryUUID(r, "author_id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	since, err := queryTime(r, "since")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	until, err := queryTime(r, "until")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, posts)
+	args := database.ListPostsDescParams{
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		AuthorID:        authorID,
+		ViewerID:        viewerID(r.Context()),
+		Since:           since,
+		Until:           until,
+		Limit:           page.FetchLimit(),
+	}
+
+	var posts []database.Post
+	if page.Descending() {
+		posts, err = cfg.db.ListPostsDesc(r.Context(), args)
+	} else {
+		posts, err = cfg.db.ListPostsAsc(r.Context(), database.ListPostsAscParams(args))
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list the posts", err)
+		return
+	}
+
+	response, err := cfg.postsWithOriginals(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original posts", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(response, page, postCursor))
+}
+
+func postCursor(post Post) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
 }
 
 func (cfg *apiConfig) handlerGetPostByID(w http.ResponseWriter, r *http.Request) {
@@ -100,18 +393,33 @@ func (cfg *apiConfig) handlerGetPostByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, Post{
-		ID:        post.ID,
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		UserID:    post.UserID,
-		Body:      post.Body,
-	})
+	visible, err := cfg.canSeePost(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check blocks", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post", nil)
+		return
+	}
+
+	response, err := cfg.postWithOriginals(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original post", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
 }
 
+// handlerChangePostByID - only the author or a moderator can edit a post.
+// The replaced body is kept in post_revisions, a new visibility alone doesn't
+// count as an edit.
 func (cfg *apiConfig) handlerChangePostByID(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		// Body and Visibility - nil keeps the current value
+		Body       *string `json:"body"`
+		Visibility *string `json:"visibility"`
 	}
 
 	postIDString := r.PathValue("post_id")
@@ -121,26 +429,121 @@ func (cfg *apiConfig) handlerChangePostByID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
 	err = decoder.Decode(&params)
 	if err != nil {
-		respondWithJSON(w, http.StatusBadRequest, "can't decode body - handlerChangePostByID")
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerChangePostByID", err)
 		return
 	}
 
-	err = cfg.db.ChangePostByID(r.Context(), database.ChangePostByIDParams{
-		Body: params.Body,
-		ID:   postID,
-	})
+	var level *visibility.Level
+	if params.Visibility != nil {
+		parsed, err := visibility.Parse(*params.Visibility)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		level = &parsed
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerChangePostByID", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	post, err := qtx.GetPostByIDForUpdate(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerChangePostByID", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerChangePostByID", err)
+		return
+	}
+
+	if post.DeletedAt.Valid {
+		respondWithError(w, http.StatusGone, "the post was deleted - handlerChangePostByID", nil)
+		return
+	}
+
+	if post.RepostOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "reposts can't be edited - handlerChangePostByID", nil)
+		return
+	}
+
+	if post.UserID != user.ID && !auth.Role(user.Role).Allows(auth.RoleModerator) {
+		respondWithError(w, http.StatusForbidden, "you can't edit this post - handlerChangePostByID", nil)
+		return
+	}
+
+	// An unchanged body has nothing to keep in the history
+	if params.Body != nil && *params.Body != post.Body {
+		_, err = qtx.CreatePostRevision(r.Context(), database.CreatePostRevisionParams{
+			ID:       uuid.New(),
+			PostID:   post.ID,
+			EditedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+			Body:     post.Body,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't save the post revision - handlerChangePostByID", err)
+			return
+		}
+
+		_, err = qtx.ChangePostByID(r.Context(), database.ChangePostByIDParams{
+			Body: *params.Body,
+			ID:   postID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "can't change the post by id - handlerChangePostByID", err)
+			return
+		}
+
+		err = qtx.DeletePostMentions(r.Context(), postID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't remove the old mentions - handlerChangePostByID", err)
+			return
+		}
+		err = qtx.CreatePostMentions(r.Context(), database.CreatePostMentionsParams{
+			PostID:    postID,
+			Usernames: visibility.Mentions(*params.Body),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't save the mentions - handlerChangePostByID", err)
+			return
+		}
+	}
+
+	if level != nil && string(*level) != post.Visibility {
+		_, err = qtx.SetPostVisibility(r.Context(), database.SetPostVisibilityParams{
+			Visibility: string(*level),
+			ID:         postID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't change the visibility - handlerChangePostByID", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't change the post by id - handlerChangePostByID", err)
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerChangePostByID", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handlerDeletePostByID - see deletePost
 func (cfg *apiConfig) handlerDeletePostByID(w http.ResponseWriter, r *http.Request) {
 	postIDString := r.PathValue("post_id")
 	postID, err := uuid.Parse(postIDString)
@@ -149,21 +552,32 @@ func (cfg *apiConfig) handlerDeletePostByID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't get header token - handlerDeletePostByID", err)
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "jwt token is not correct one - handlerDeletePostByID", err)
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerDeletePostByID", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
 
-	post, err := cfg.db.GetPostByID(r.Context(), postID)
+	post, err := qtx.GetPostByIDForUpdate(r.Context(), postID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't get the post by id - handlerDeletePostByID", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't get the post by id - handlerDeletePostByID", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post by id - handlerDeletePostByID", err)
+		return
+	}
+
+	if post.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "the post was already deleted - handlerDeletePostByID", nil)
 		return
 	}
 
@@ -172,15 +586,52 @@ func (cfg *apiConfig) handlerDeletePostByID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = cfg.db.DeletePostByID(r.Context(), postID)
+	err = deletePost(r.Context(), qtx, post)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't delete the post - handlerDeletePostByID", err)
+		respondWithError(w, http.StatusInternalServerError, "can't delete the post - handlerDeletePostByID", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerDeletePostByID", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// deletePost - a post with replies becomes a tombstone, other posts are
+// removed. Either way reposts of it go too.
+func deletePost(ctx context.Context, qtx *database.Queries, post database.Post) error {
+	if post.ReplyCount > 0 {
+		err := qtx.TombstonePost(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		// Earlier bodies must not outlive the deleted post
+		err = qtx.DeletePostRevisions(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		return qtx.DeleteRepostsOf(ctx, uuid.NullUUID{UUID: post.ID, Valid: true})
+	}
+
+	err := qtx.DeletePostByID(ctx, post.ID)
+	if err != nil {
+		return err
+	}
+	if post.RepostOfID.Valid {
+		err = qtx.DecrementPostRepostCount(ctx, post.RepostOfID.UUID)
+		if err != nil {
+			return err
+		}
+	}
+	return removeFromThread(ctx, qtx, post)
+}
+
+// handlerLikePost - likes a post, replacing another reaction I left on it.
+// Liking it again answers 200 with the existing like instead of 201.
 func (cfg *apiConfig) handlerLikePost(w http.ResponseWriter, r *http.Request) {
 	type response struct {
 		PostsLike
@@ -193,43 +644,48 @@ func (cfg *apiConfig) handlerLikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't get header bearer - handlerLikePost", err)
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	post, err := cfg.db.GetPostByID(r.Context(), postID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't  validate jwt token - handlerLikePost", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerLikePost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerLikePost", err)
 		return
 	}
 
-	// Checks if the user already liked the post
-	err = cfg.db.CheckIfUserLikeAlready(r.Context(), userID)
-	if err == nil {
-		respondWithError(w, http.StatusBadRequest, "you can only like this post once - handlerLikePost", err)
+	visible, err := cfg.canSeePost(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check blocks - handlerLikePost", err)
 		return
 	}
-
-	postLike, err := cfg.db.LikePost(r.Context(), database.LikePostParams{
-		ID:     uuid.New(),
-		PostID: postID,
-		UserID: userID,
-	})
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't like post - handlerLikePost", err)
+	if !visible || post.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerLikePost", nil)
 		return
 	}
 
-	// Increments the likes column in posts table
-	err = cfg.db.IncrementPostLike(r.Context(), postID)
+	postLike, created, err := cfg.likes.Like(r.Context(), postID, userID)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't increment post's likes - handlerLikePost", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerLikePost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't like post - handlerLikePost", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, response{
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	respondWithJSON(w, status, response{
 		PostsLike: PostsLike{
 			ID:        postLike.ID,
 			PostID:    postLike.PostID,
@@ -239,53 +695,82 @@ func (cfg *apiConfig) handlerLikePost(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handlerDislikePost - removes my like from a post, answers 204 whether the
+// post was liked or not
 func (cfg *apiConfig) handlerDislikePost(w http.ResponseWriter, r *http.Request) {
-	postIDString := r.PathValue("likepost_id")
+	postIDString := r.PathValue("post_id")
 	postID, err := uuid.Parse(postIDString)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't parse post id - handlerDislikePost", err)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't get bearer from header - handlerDislikePost", err)
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	_, err = cfg.likes.Unlike(r.Context(), postID, userID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't validate jwt - handlerDislikePost", err)
+		respondWithError(w, http.StatusInternalServerError, "can't dislike the post - handlerDislikePost", err)
 		return
 	}
 
-	err = cfg.db.DislikePost(r.Context(), database.DislikePostParams{
-		UserID: userID,
-		PostID: postID,
-	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListLikePost - a page of likes, filtered by post_id and user_id
+func (cfg *apiConfig) handlerListLikePost(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.ParseParams(r.URL.Query())
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't dislike the post - handlerDislikePost", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
-
-	// Decrements the likes column in posts table
-	err = cfg.db.DecrementPostLike(r.Context(), postID)
+	postID, err := queryUUID(r, "post_id")
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't decrement post's likes - handlerDislikePost", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func (cfg *apiConfig) handlerListLikePost(w http.ResponseWriter, r *http.Request) {
-	likePosts, err := cfg.db.ListLikePost(r.Context())
+	userID, err := queryUUID(r, "user_id")
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't list LikePost table - handlerListLikePost", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, likePosts)
+	args := database.ListLikesDescParams{
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		PostID:          postID,
+		UserID:          userID,
+		ViewerID:        viewerID(r.Context()),
+		Limit:           page.FetchLimit(),
+	}
+
+	response := []PostsLike{}
+	if page.Descending() {
+		likes, err := cfg.db.ListLikesDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list LikePost table - handlerListLikePost", err)
+			return
+		}
+		for _, like := range likes {
+			response = append(response, PostsLike(like))
+		}
+	} else {
+		likes, err := cfg.db.ListLikesAsc(r.Context(), database.ListLikesAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list LikePost table - handlerListLikePost", err)
+			return
+		}
+		for _, like := range likes {
+			response = append(response, PostsLike(like))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(response, page, func(like PostsLike) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: like.CreatedAt, ID: like.ID}
+	}))
 }
 
 func (cfg *apiConfig) handlerGetPostLikes(w http.ResponseWriter, r *http.Request) {
@@ -300,6 +785,19 @@ func (cfg *apiConfig) handlerGetPostLikes(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	visible, err := cfg.db.PostVisible(r.Context(), database.PostVisibleParams{
+		PostID:   postID,
+		ViewerID: viewerID(r.Context()),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check the post's visibility - handlerGetPostLikes", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerGetPostLikes", nil)
+		return
+	}
+
 	likes, err := cfg.db.GetPostLikes(r.Context(), postID)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't get post's likes - handlerGetPostLikes", err)
@@ -312,11 +810,17 @@ func (cfg *apiConfig) handlerGetPostLikes(w http.ResponseWriter, r *http.Request
 }
 
 func (cfg *apiConfig) handlerGetMostLikedPost(w http.ResponseWriter, r *http.Request) {
-	posts, err := cfg.db.GetMostLikedPosts(r.Context())
+	posts, err := cfg.db.GetMostLikedPosts(r.Context(), viewerID(r.Context()))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't get most likes posts - handlerGetMostLikedPost", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, posts)
+	response, err := cfg.postsWithOriginals(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original posts - handlerGetMostLikedPost", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
 }
\ No newline at end of file
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+type PostReaction struct {
+	ID        uuid.UUID `json:"id"`
+	PostID    uuid.UUID `json:"post_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Reaction  string    `json:"reaction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func databaseReactionToReaction(reaction database.PostReaction) PostReaction {
+	return PostReaction{
+		ID:        reaction.ID,
+		PostID:    reaction.PostID,
+		UserID:    reaction.UserID,
+		Reaction:  reaction.Reaction,
+		CreatedAt: reaction.CreatedAt,
+	}
+}
+
+// ReactionUser - an entry of the list of who reacted to a post
+type ReactionUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Reaction  string    `json:"reaction"`
+	CreatedAt time.Time `json:"reacted_at"`
+}
+
+// handlerListReactionTypes - the reactions this deployment offers
+func (cfg *apiConfig) handlerListReactionTypes(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, cfg.reactionSet.List())
+}
+
+// handlerReactToPost - sets my reaction on a post, replacing the one I left
+// before. Answers 201 for my first reaction on the post and 200 after that.
+func (cfg *apiConfig) handlerReactToPost(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Reaction string `json:"reaction"`
+	}
+
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerReactToPost", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerReactToPost", err)
+		return
+	}
+
+	if !cfg.reactionSet.Has(params.Reaction) {
+		respondWithError(w, http.StatusBadRequest, "unknown reaction, see GET /api/reactions", nil)
+		return
+	}
+
+	post, err := cfg.db.GetPostByID(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerReactToPost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerReactToPost", err)
+		return
+	}
+
+	visible, err := cfg.canSeePost(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check the post's visibility - handlerReactToPost", err)
+		return
+	}
+	if !visible || post.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerReactToPost", nil)
+		return
+	}
+
+	reaction, previous, err := cfg.reactions.React(r.Context(), postID, userID, params.Reaction)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerReactToPost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't react to the post - handlerReactToPost", err)
+		return
+	}
+
+	status := http.StatusOK
+	if previous == "" {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, databaseReactionToReaction(reaction))
+}
+
+// handlerRemoveReaction - removes my reaction from a post, answers 204 whether
+// I had reacted or not
+func (cfg *apiConfig) handlerRemoveReaction(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerRemoveReaction", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	_, err = cfg.reactions.Unreact(r.Context(), postID, userID, "")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't remove the reaction - handlerRemoveReaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListPostReactions - a page of who reacted to a post with what, the
+// most recent first, filtered by reaction
+func (cfg *apiConfig) handlerListPostReactions(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerListPostReactions", err)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	reaction := sql.NullString{}
+	if value := r.URL.Query().Get("reaction"); value != "" {
+		reaction = sql.NullString{String: value, Valid: true}
+	}
+
+	visible, err := cfg.db.PostVisible(r.Context(), database.PostVisibleParams{
+		PostID:   postID,
+		ViewerID: viewerID(r.Context()),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check the post's visibility - handlerListPostReactions", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerListPostReactions", nil)
+		return
+	}
+
+	args := database.ListReactionsDescParams{
+		PostID:          postID,
+		ViewerID:        viewerID(r.Context()),
+		Reaction:        reaction,
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	users := []ReactionUser{}
+	if page.Descending() {
+		rows, err := cfg.db.ListReactionsDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list reactions - handlerListPostReactions", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, ReactionUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListReactionsAsc(r.Context(), database.ListReactionsAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list reactions - handlerListPostReactions", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, ReactionUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, func(user ReactionUser) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}))
+}
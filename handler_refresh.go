@@ -1,15 +1,33 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+const refreshTokenTTL = time.Hour * 24 * 60
+
+// refreshTokenRotated - the revoked_reason of a token that was replaced by
+// its successor. The other reasons are logout and reuse.
+const refreshTokenRotated = "rotated"
+
+var (
+	errRefreshTokenReused      = errors.New("refresh token reuse detected")
+	errRefreshTokenRevoked     = errors.New("refresh token was revoked")
+	errRefreshTokenOtherClient = errors.New("refresh token was issued to another client")
 )
 
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	type responce struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	refreshToken, err := auth.GetBearerToken(r.Header)
@@ -18,15 +36,19 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
+	user, newRefreshToken, err := cfg.rotateRefreshToken(r.Context(), refreshToken, sql.NullString{})
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't get user from refresh token", err)
+		respondWithError(w, http.StatusUnauthorized, "can't refresh token", err)
 		return
 	}
 
 	accessToken, err := auth.MakeJWT(
-		user.ID,
-		cfg.jwtSecret,
+		auth.Claims{
+			UserID:    user.ID,
+			Role:      auth.Role(user.Role),
+			SessionID: newRefreshToken.FamilyID,
+		},
+		cfg.jwtKeys,
 		time.Hour,
 	)
 	if err != nil {
@@ -35,10 +57,121 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondWithJSON(w, http.StatusOK, responce{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken.Token,
 	})
 }
 
+// rotateRefreshToken - revokes the presented refresh token and issues its
+// successor in the same family. Presenting an already rotated token revokes
+// the whole family, because only a stolen copy can still be in circulation.
+// A token revoked by logout is just refused.
+// clientID has to match the client the token was issued to, it is null for
+// first-party logins.
+func (cfg *apiConfig) rotateRefreshToken(ctx context.Context, token string, clientID sql.NullString) (database.User, database.RefreshToken, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	stored, err := qtx.GetRefreshToken(ctx, token)
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	if stored.ClientID != clientID {
+		return database.User{}, database.RefreshToken{}, errRefreshTokenOtherClient
+	}
+
+	if stored.RevokedAt.Valid {
+		return database.User{}, database.RefreshToken{}, cfg.rejectRevokedToken(ctx, tx, qtx, stored)
+	}
+
+	if time.Now().UTC().After(stored.ExpiresAt) {
+		return database.User{}, database.RefreshToken{}, errors.New("refresh token expired")
+	}
+
+	newToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	// Only one concurrent request can rotate a token, the loser sees it as
+	// reused. It can also have been logged out in the meantime.
+	_, err = qtx.RotateRefreshToken(ctx, database.RotateRefreshTokenParams{
+		Token:      stored.Token,
+		ReplacedBy: sql.NullString{String: newToken, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		stored, err = qtx.GetRefreshToken(ctx, token)
+		if err != nil {
+			return database.User{}, database.RefreshToken{}, err
+		}
+		return database.User{}, database.RefreshToken{}, cfg.rejectRevokedToken(ctx, tx, qtx, stored)
+	}
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	rotated, err := qtx.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     newToken,
+		UserID:    stored.UserID,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+		FamilyID:  stored.FamilyID,
+		ClientID:  stored.ClientID,
+		Scopes:    stored.Scopes,
+	})
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	err = qtx.TouchSession(ctx, stored.FamilyID)
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	user, err := qtx.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.User{}, database.RefreshToken{}, err
+	}
+
+	return user, rotated, nil
+}
+
+// rejectRevokedToken - the error for presenting a revoked token. Only a
+// rotated token counts as reuse.
+func (cfg *apiConfig) rejectRevokedToken(ctx context.Context, tx *sql.Tx, qtx *database.Queries, stored database.RefreshToken) error {
+	if stored.RevokedReason.String != refreshTokenRotated {
+		return errRefreshTokenRevoked
+	}
+	return cfg.revokeReusedFamily(ctx, tx, qtx, stored)
+}
+
+func (cfg *apiConfig) revokeReusedFamily(ctx context.Context, tx *sql.Tx, qtx *database.Queries, stored database.RefreshToken) error {
+	log.Printf("Refresh token reuse detected for user %s, revoking token family %s", stored.UserID, stored.FamilyID)
+
+	err := qtx.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.FlagSessionReuse(ctx, stored.FamilyID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return errRefreshTokenReused
+}
+
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -46,7 +179,7 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	err = cfg.revokeRefreshToken(r.Context(), refreshToken, sql.NullString{})
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't revoke token", err)
 		return
@@ -54,3 +187,21 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// revokeRefreshToken - revokes the token and logs out the session it belongs
+// to. Used for first-party logins and for grants of third-party clients.
+func (cfg *apiConfig) revokeRefreshToken(ctx context.Context, token string, clientID sql.NullString) error {
+	stored, err := cfg.db.GetRefreshToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if stored.ClientID != clientID {
+		return errRefreshTokenOtherClient
+	}
+
+	err = cfg.revokeSession(ctx, stored.UserID, stored.FamilyID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+const (
+	defaultConversationDepth = 3
+	maxConversationDepth     = 10
+	// maxConversationReplies - replies below the first level loaded for one
+	// page of a conversation. Clients load the rest through the reply's own
+	// conversation, reply_count tells them that there is more.
+	maxConversationReplies = 200
+)
+
+var errParentNotFound = errors.New("parent post not found")
+
+// ConversationNode - a reply together with the replies loaded below it
+type ConversationNode struct {
+	Post
+	Replies []ConversationNode `json:"replies"`
+}
+
+// createReply - creates a reply in the thread of parentID within the
+// transaction of qtx. Tombstones can't get new replies.
+func (cfg *apiConfig) createReply(ctx context.Context, qtx *database.Queries, params database.CreatePostParams, parentID uuid.UUID) (database.Post, error) {
+	parent, err := qtx.GetPostByIDForUpdate(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Post{}, errParentNotFound
+		}
+		return database.Post{}, err
+	}
+	// Replies to a repost go to the reposted post
+	if parent.RepostOfID.Valid {
+		parent, err = qtx.GetPostByIDForUpdate(ctx, parent.RepostOfID.UUID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.Post{}, errParentNotFound
+			}
+			return database.Post{}, err
+		}
+	}
+	if parent.DeletedAt.Valid {
+		return database.Post{}, errParentNotFound
+	}
+	visible, err := cfg.canSeePost(ctx, parent)
+	if err != nil {
+		return database.Post{}, err
+	}
+	if !visible {
+		return database.Post{}, errParentNotFound
+	}
+
+	params.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	params.RootID = parent.RootID
+	if !params.RootID.Valid {
+		params.RootID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	post, err := qtx.CreatePost(ctx, params)
+	if err != nil {
+		return database.Post{}, err
+	}
+
+	err = qtx.IncrementPostReplyCount(ctx, parent.ID)
+	if err != nil {
+		return database.Post{}, err
+	}
+
+	return post, nil
+}
+
+// removeFromThread - updates the reply counts after post was removed. A
+// tombstone that loses its last reply isn't needed anymore and goes as well.
+func removeFromThread(ctx context.Context, qtx *database.Queries, post database.Post) error {
+	for post.ParentID.Valid {
+		err := qtx.DecrementPostReplyCount(ctx, post.ParentID.UUID)
+		if err != nil {
+			return err
+		}
+
+		parent, err := qtx.GetPostByIDForUpdate(ctx, post.ParentID.UUID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		if !parent.DeletedAt.Valid || parent.ReplyCount > 0 {
+			return nil
+		}
+
+		err = qtx.DeletePostByID(ctx, parent.ID)
+		if err != nil {
+			return err
+		}
+		post = parent
+	}
+	return nil
+}
+
+// handlerGetConversation - a post with a page of its direct replies, each
+// with its replies up to depth levels below the post
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Post Post `json:"post"`
+		pagination.Page[ConversationNode]
+	}
+
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerGetConversation", err)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	depth := defaultConversationDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		depth, err = strconv.Atoi(value)
+		if err != nil || depth < 1 || depth > maxConversationDepth {
+			respondWithError(w, http.StatusBadRequest, "depth must be between 1 and 10", err)
+			return
+		}
+	}
+
+	post, err := cfg.db.GetPostByID(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerGetConversation", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerGetConversation", err)
+		return
+	}
+
+	visible, err := cfg.canSeePost(r.Context(), post)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check blocks - handlerGetConversation", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerGetConversation", nil)
+		return
+	}
+
+	args := database.ListRepliesDescParams{
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		ParentID:        uuid.NullUUID{UUID: post.ID, Valid: true},
+		ViewerID:        viewerID(r.Context()),
+		Limit:           page.FetchLimit(),
+	}
+
+	var replies []database.Post
+	if page.Descending() {
+		replies, err = cfg.db.ListRepliesDesc(r.Context(), args)
+	} else {
+		replies, err = cfg.db.ListRepliesAsc(r.Context(), database.ListRepliesAscParams(args))
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list replies - handlerGetConversation", err)
+		return
+	}
+
+	// The extra row only tells if there is a next page, it isn't shown
+	shown := replies
+	if len(shown) > int(page.Limit) {
+		shown = shown[:page.Limit]
+	}
+
+	children, err := cfg.loadReplyTree(r.Context(), shown, depth-1)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the reply tree - handlerGetConversation", err)
+		return
+	}
+
+	all := append([]database.Post{post}, replies...)
+	for _, posts := range children {
+		all = append(all, posts...)
+	}
+	converted, err := cfg.postsWithOriginals(r.Context(), all)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original posts - handlerGetConversation", err)
+		return
+	}
+	byID := make(map[uuid.UUID]Post, len(converted))
+	for _, p := range converted {
+		byID[p.ID] = p
+	}
+
+	nodes := make([]ConversationNode, 0, len(replies))
+	for _, reply := range replies {
+		nodes = append(nodes, buildConversationNode(reply.ID, byID, children))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Post: byID[post.ID],
+		Page: pagination.NewPage(nodes, page, func(node ConversationNode) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: node.CreatedAt, ID: node.ID}
+		}),
+	})
+}
+
+// loadReplyTree - loads levels of replies below posts, one query per level,
+// and returns them grouped by parent
+func (cfg *apiConfig) loadReplyTree(ctx context.Context, posts []database.Post, levels int) (map[uuid.UUID][]database.Post, error) {
+	children := map[uuid.UUID][]database.Post{}
+	remaining := maxConversationReplies
+
+	level := posts
+	for i := 0; i < levels && remaining > 0; i++ {
+		parentIDs := make([]uuid.UUID, 0, len(level))
+		for _, post := range level {
+			if post.ReplyCount > 0 {
+				parentIDs = append(parentIDs, post.ID)
+			}
+		}
+		if len(parentIDs) == 0 {
+			break
+		}
+
+		replies, err := cfg.db.ListRepliesByParents(ctx, database.ListRepliesByParentsParams{
+			ParentIds: parentIDs,
+			ViewerID:  viewerID(ctx),
+			Limit:     int32(remaining),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, reply := range replies {
+			children[reply.ParentID.UUID] = append(children[reply.ParentID.UUID], reply)
+		}
+		remaining -= len(replies)
+		level = replies
+	}
+
+	return children, nil
+}
+
+func buildConversationNode(id uuid.UUID, posts map[uuid.UUID]Post, children map[uuid.UUID][]database.Post) ConversationNode {
+	node := ConversationNode{
+		Post:    posts[id],
+		Replies: make([]ConversationNode, 0, len(children[id])),
+	}
+	for _, child := range children[id] {
+		node.Replies = append(node.Replies, buildConversationNode(child.ID, posts, children))
+	}
+	return node
+}
@@ -6,8 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/imhasandl/go-restapi/internal/auth"
 	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
 )
 
 type ReportPost struct {
@@ -42,15 +42,19 @@ func (cfg *apiConfig) handlerReportPost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	visible, err := cfg.canSeePost(r.Context(), post)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid header bearer - GetBearerToken", err)
+		respondWithError(w, http.StatusInternalServerError, "can't check the post's visibility - handlerReportPost", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerReportPost", nil)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't validate JWT", err)
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
 		return
 	}
 
@@ -77,14 +81,45 @@ func (cfg *apiConfig) handlerReportPost(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handlerListAllReports - a page of reports, filtered by post_id
 func (cfg *apiConfig) handlerListAllReports(w http.ResponseWriter, r *http.Request) {
-	reports, err := cfg.db.ListAllReports(r.Context())
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	postID, err := queryUUID(r, "post_id")
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't get all reports from db - handlerListAllReports", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, reports)
+	args := database.ListReportsDescParams{
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		PostID:          postID,
+		Limit:           page.FetchLimit(),
+	}
+
+	var reports []database.Report
+	if page.Descending() {
+		reports, err = cfg.db.ListReportsDesc(r.Context(), args)
+	} else {
+		reports, err = cfg.db.ListReportsAsc(r.Context(), database.ListReportsAscParams(args))
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't get all reports from db - handlerListAllReports", err)
+		return
+	}
+
+	response := make([]ReportPost, 0, len(reports))
+	for _, report := range reports {
+		response = append(response, ReportPost(report))
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(response, page, func(report ReportPost) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: report.CreatedAt, ID: report.ReportID}
+	}))
 }
 
 func (cfg *apiConfig) handlerDeleteReportByID(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/visibility"
+)
+
+// originalPost - the post behind postID, following a repost to the post it
+// reposts. Tombstones count as missing.
+func originalPost(ctx context.Context, q *database.Queries, postID uuid.UUID) (database.Post, error) {
+	post, err := q.GetPostByID(ctx, postID)
+	if err != nil {
+		return database.Post{}, err
+	}
+
+	if post.RepostOfID.Valid {
+		post, err = q.GetPostByID(ctx, post.RepostOfID.UUID)
+		if err != nil {
+			return database.Post{}, err
+		}
+	}
+
+	if post.DeletedAt.Valid {
+		return database.Post{}, sql.ErrNoRows
+	}
+	return post, nil
+}
+
+// handlerRepost - shares a post. Reposting a repost shares its original,
+// every user can repost a post only once.
+func (cfg *apiConfig) handlerRepost(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerRepost", err)
+		return
+	}
+
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "verify your email before posting", nil)
+		return
+	}
+
+	original, err := originalPost(r.Context(), cfg.db, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerRepost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerRepost", err)
+		return
+	}
+
+	visible, err := cfg.canSeePost(r.Context(), original)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't check blocks - handlerRepost", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "can't find the post - handlerRepost", nil)
+		return
+	}
+	if !visibility.Level(original.Visibility).Shareable() {
+		respondWithError(w, http.StatusBadRequest, "only public and unlisted posts can be reposted", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerRepost", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Locks the original, so it can't become a tombstone while we repost it
+	original, err = qtx.GetPostByIDForUpdate(r.Context(), original.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerRepost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerRepost", err)
+		return
+	}
+	if original.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "the post was deleted - handlerRepost", nil)
+		return
+	}
+
+	repost, err := qtx.CreatePost(r.Context(), database.CreatePostParams{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		RepostOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		Visibility: string(visibility.Public),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "you already reposted this post", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't repost - handlerRepost", err)
+		return
+	}
+
+	err = qtx.IncrementPostRepostCount(r.Context(), original.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't increment the repost count - handlerRepost", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerRepost", err)
+		return
+	}
+
+	cfg.fanOutPost(r.Context(), user, repost)
+
+	response, err := cfg.postWithOriginals(r.Context(), repost)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original post - handlerRepost", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// handlerUndoRepost - removes my repost of a post
+func (cfg *apiConfig) handlerUndoRepost(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse the post id - handlerUndoRepost", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	post, err := cfg.db.GetPostByID(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find the post - handlerUndoRepost", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the post - handlerUndoRepost", err)
+		return
+	}
+	originalID := post.ID
+	if post.RepostOfID.Valid {
+		originalID = post.RepostOfID.UUID
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't start transaction - handlerUndoRepost", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	repost, err := qtx.GetRepost(r.Context(), database.GetRepostParams{
+		UserID:     userID,
+		RepostOfID: uuid.NullUUID{UUID: originalID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "you haven't reposted this post", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't get the repost - handlerUndoRepost", err)
+		return
+	}
+
+	err = deletePost(r.Context(), qtx, repost)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't delete the repost - handlerUndoRepost", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't commit transaction - handlerUndoRepost", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
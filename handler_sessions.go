@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	DeviceName string    `json:"device_name,omitempty"`
+	ClientID   string    `json:"client_id,omitempty"`
+	Current    bool      `json:"current"`
+}
+
+// grant - what a session may do. A first-party login has no client and no
+// scope limits, a session started by a third-party client is limited to the
+// scopes the user consented to.
+type grant struct {
+	clientID sql.NullString
+	scopes   []auth.Scope
+}
+
+func (g grant) scopeNames() []string {
+	if !g.clientID.Valid {
+		return nil
+	}
+	names := make([]string, 0, len(g.scopes))
+	for _, scope := range g.scopes {
+		names = append(names, string(scope))
+	}
+	return names
+}
+
+func scopesFromNames(names []string) []auth.Scope {
+	scopes := make([]auth.Scope, 0, len(names))
+	for _, name := range names {
+		scopes = append(scopes, auth.Scope(name))
+	}
+	return scopes
+}
+
+// sessionChecker - rejects access tokens whose session was revoked
+func (cfg *apiConfig) sessionChecker(ctx context.Context) auth.SessionChecker {
+	return func(sessionID uuid.UUID) error {
+		session, err := cfg.db.GetSessionByID(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.RevokedAt.Valid {
+			return errors.New("session was revoked")
+		}
+		return nil
+	}
+}
+
+func (cfg *apiConfig) handlerListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+	currentSessionID, _ := sessionIDFromContext(r.Context())
+
+	sessions, err := cfg.db.ListActiveSessionsByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't list sessions - handlerListSessions", err)
+		return
+	}
+
+	response := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, Session{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IpAddress,
+			DeviceName: session.DeviceName.String,
+			ClientID:   session.ClientID.String,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionIDString := r.PathValue("session_id")
+	sessionID, err := uuid.Parse(sessionIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse session id - handlerRevokeSession", err)
+		return
+	}
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	err = cfg.revokeSession(r.Context(), userID, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find active session - handlerRevokeSession", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't revoke session - handlerRevokeSession", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	err := cfg.revokeAllSessions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't revoke sessions - handlerRevokeAllSessions", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createSession - starts a session for a fresh login and returns its first refresh token
+func (cfg *apiConfig) createSession(r *http.Request, userID uuid.UUID, deviceName string, g grant) (database.Session, string, error) {
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return database.Session{}, "", err
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		return database.Session{}, "", err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	session, err := qtx.CreateSession(r.Context(), database.CreateSessionParams{
+		ID:         uuid.New(),
+		UserID:     userID,
+		UserAgent:  r.UserAgent(),
+		IpAddress:  clientIP(r),
+		DeviceName: sql.NullString{String: deviceName, Valid: deviceName != ""},
+		ClientID:   g.clientID,
+	})
+	if err != nil {
+		return database.Session{}, "", err
+	}
+
+	_, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		Token:     refreshToken,
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+		FamilyID:  session.ID,
+		ClientID:  g.clientID,
+		Scopes:    g.scopeNames(),
+	})
+	if err != nil {
+		return database.Session{}, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Session{}, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// revokeSession - ends one session of the user together with its refresh tokens
+func (cfg *apiConfig) revokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.RevokeSession(ctx, database.RevokeSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = qtx.RevokeRefreshTokensByFamily(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// revokeAllSessions - logs the user out on every device
+func (cfg *apiConfig) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.RevokeAllSessionsForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.RevokeRefreshTokensByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
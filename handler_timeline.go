@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
+)
+
+const (
+	// fanOutMaxFollowers - posts of accounts with more followers aren't
+	// copied into the timelines of their followers. They stay unmarked as
+	// fanned out and the timeline reads them from the posts table instead.
+	fanOutMaxFollowers = 10000
+	// timelineBackfillPosts - recent posts copied into my timeline when I follow someone
+	timelineBackfillPosts = 50
+)
+
+// fanOutPost - copies a new post or repost into the timelines of the
+// author's followers and marks it as fanned out. Replies don't go to
+// timelines.
+func (cfg *apiConfig) fanOutPost(ctx context.Context, author database.User, post database.Post) {
+	if post.ParentID.Valid || author.FollowerCount > fanOutMaxFollowers {
+		return
+	}
+
+	err := cfg.db.FanOutPost(ctx, database.FanOutPostParams{
+		PostID:    post.ID,
+		AuthorID:  author.ID,
+		CreatedAt: post.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("Can't fan out post %s: %s", post.ID, err)
+	}
+}
+
+// backfillTimeline - fills my timeline with recent posts of someone I just
+// followed. Large accounts are backfilled too, their posts from before they
+// grew past fanOutMaxFollowers were fanned out and aren't read from the posts
+// table.
+func (cfg *apiConfig) backfillTimeline(ctx context.Context, userID, followeeID uuid.UUID) {
+	err := cfg.db.BackfillTimeline(ctx, database.BackfillTimelineParams{
+		UserID:   userID,
+		AuthorID: followeeID,
+		Limit:    timelineBackfillPosts,
+	})
+	if err != nil {
+		log.Printf("Can't backfill timeline of %s: %s", userID, err)
+	}
+}
+
+// handlerHomeTimeline - posts and reposts of the accounts I follow and my own,
+// newest first. Most of them were fanned out into timeline_entries when they
+// were posted, my own posts and those that weren't fanned out, because their
+// author was too large at the time, are read from the posts table and merged
+// in.
+func (cfg *apiConfig) handlerHomeTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	timelineArgs := database.ListTimelineDescParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+	authorArgs := database.ListUnfannedTimelinePostsDescParams{
+		ViewerID:        userID,
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	var posts []database.Post
+	if page.Descending() {
+		rows, err := cfg.db.ListTimelineDesc(r.Context(), timelineArgs)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list the timeline - handlerHomeTimeline", err)
+			return
+		}
+		for _, row := range rows {
+			posts = append(posts, row.Post)
+		}
+		pulled, err := cfg.db.ListUnfannedTimelinePostsDesc(r.Context(), authorArgs)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list posts of followed accounts - handlerHomeTimeline", err)
+			return
+		}
+		posts = append(posts, pulled...)
+	} else {
+		rows, err := cfg.db.ListTimelineAsc(r.Context(), database.ListTimelineAscParams(timelineArgs))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list the timeline - handlerHomeTimeline", err)
+			return
+		}
+		for _, row := range rows {
+			posts = append(posts, row.Post)
+		}
+		pulled, err := cfg.db.ListUnfannedTimelinePostsAsc(r.Context(), database.ListUnfannedTimelinePostsAscParams(authorArgs))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list posts of followed accounts - handlerHomeTimeline", err)
+			return
+		}
+		posts = append(posts, pulled...)
+	}
+
+	posts = mergeTimelinePosts(posts, page)
+
+	response, err := cfg.postsWithOriginals(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "can't load the original posts - handlerHomeTimeline", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(response, page, postCursor))
+}
+
+// mergeTimelinePosts - both sources are already in page order, but a post that
+// wasn't fanned out can also have been backfilled into the timeline
+func mergeTimelinePosts(posts []database.Post, page pagination.Params) []database.Post {
+	slices.SortFunc(posts, func(a, b database.Post) int {
+		c := a.CreatedAt.Compare(b.CreatedAt)
+		if c == 0 {
+			c = bytes.Compare(a.ID[:], b.ID[:])
+		}
+		if page.Descending() {
+			return -c
+		}
+		return c
+	})
+	posts = slices.CompactFunc(posts, func(a, b database.Post) bool {
+		return a.ID == b.ID
+	})
+
+	if len(posts) > int(page.FetchLimit()) {
+		posts = posts[:page.FetchLimit()]
+	}
+	return posts
+}
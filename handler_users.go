@@ -1,23 +1,64 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/imhasandl/go-restapi/internal/auth"
 	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/pagination"
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Email     string    `json:"email"`
-	Username  string    `json:"username"`
-	Password  string    `json:"-"`
-	IsPremium bool      `json:"is_premium"`
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Email          string    `json:"email"`
+	Username       string    `json:"username"`
+	Password       string    `json:"-"`
+	IsPremium      bool      `json:"is_premium"`
+	Role           string    `json:"role"`
+	FollowerCount  int32     `json:"follower_count"`
+	FollowingCount int32     `json:"following_count"`
+	IsPrivate      bool      `json:"is_private"`
+}
+
+func databaseUserToUser(user database.User) User {
+	return User{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		Email:          user.Email,
+		Username:       user.Username,
+		IsPremium:      user.IsPremium,
+		Role:           user.Role,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+		IsPrivate:      user.IsPrivate,
+	}
+}
+
+// Me - my own account. Only responses about the caller's account use it, the
+// public lookups answer with User.
+type Me struct {
+	User
+	EmailVerified bool   `json:"email_verified"`
+	PendingEmail  string `json:"pending_email,omitempty"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+}
+
+func databaseUserToMe(user database.User) Me {
+	return Me{
+		User:          databaseUserToUser(user),
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		PendingEmail:  user.PendingEmail.String,
+		MFAEnabled:    user.TotpEnabledAt.Valid,
+	}
 }
 
 func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request) {
@@ -27,7 +68,7 @@ func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request)
 		Password string `json:"password"`
 	}
 	type response struct {
-		User
+		Me
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -40,14 +81,20 @@ func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request)
 
 	_, err = cfg.db.CheckIfUsernameOrEmailTaken(r.Context(), database.CheckIfUsernameOrEmailTakenParams{
 		Username: params.Username,
-		Email: params.Email,
+		Email:    params.Email,
 	})
 	if err == nil {
 		respondWithError(w, http.StatusUnauthorized, "username or email alredy taken", err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	err = cfg.passwordPolicy.Validate(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't hash the password", err)
 		return
@@ -64,15 +111,14 @@ func (cfg *apiConfig) handlerUserCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The account exists either way, the user can ask for a new mail later
+	err = cfg.sendEmailVerification(r.Context(), user.ID, user.Email)
+	if err != nil {
+		log.Printf("Can't send verification email to new user %s: %s", user.ID, err)
+	}
+
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:        user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email:     user.Email,
-			Username:  user.Username,
-			IsPremium: user.IsPremium,
-		},
+		Me: databaseUserToMe(user),
 	})
 }
 
@@ -99,14 +145,7 @@ func (cfg *apiConfig) handlerGetUserByEmail(w http.ResponseWriter, r *http.Reque
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:        user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email:     user.Email,
-			Username:  user.Username,
-			IsPremium: user.IsPremium,
-		},
+		User: databaseUserToUser(user),
 	})
 }
 
@@ -133,14 +172,7 @@ func (cfg *apiConfig) handlerGetUserByUsername(w http.ResponseWriter, r *http.Re
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:        user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email:     user.Email,
-			Username:  user.Username,
-			IsPremium: user.IsPremium,
-		},
+		User: databaseUserToUser(user),
 	})
 }
 
@@ -148,9 +180,11 @@ func (cfg *apiConfig) handlerUserChange(w http.ResponseWriter, r *http.Request)
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		// IsPrivate - nil keeps the current setting
+		IsPrivate *bool `json:"is_private"`
 	}
 	type response struct {
-		User
+		Me
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -161,43 +195,71 @@ func (cfg *apiConfig) handlerUserChange(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't hash the password", err)
+	currentUser, ok := userFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "you must be logged in", nil)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't get header token", err)
-		return
+	// Without a new password the current one is kept
+	hashedPassword := currentUser.Password
+	if params.Password != "" {
+		err = cfg.passwordPolicy.Validate(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		hashedPassword, err = cfg.passwordHasher.Hash(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "can't hash the password", err)
+			return
+		}
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "can't validate jwt", err)
-		return
+	// A new email is only stored as pending until the user confirms it.
+	// Without an email a pending change is kept, sending the current email
+	// cancels it.
+	pendingEmail := currentUser.PendingEmail
+	sendVerification := false
+	if params.Email == currentUser.Email {
+		pendingEmail = sql.NullString{}
+	} else if params.Email != "" {
+		_, err = cfg.db.GetUserByEmail(r.Context(), params.Email)
+		if err == nil {
+			respondWithError(w, http.StatusConflict, "email already taken", nil)
+			return
+		}
+		pendingEmail = sql.NullString{String: params.Email, Valid: true}
+		sendVerification = true
+	}
+
+	isPrivate := currentUser.IsPrivate
+	if params.IsPrivate != nil {
+		isPrivate = *params.IsPrivate
 	}
 
 	user, err := cfg.db.ChangeUser(r.Context(), database.ChangeUserParams{
-		Email:    params.Email,
-		Password: hashedPassword,
-		ID:       userID,
+		PendingEmail: pendingEmail,
+		Password:     hashedPassword,
+		IsPrivate:    isPrivate,
+		ID:           currentUser.ID,
 	})
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "can't change user", err)
 		return
 	}
 
+	if sendVerification {
+		err = cfg.sendEmailVerification(r.Context(), user.ID, pendingEmail.String)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't send verification email", err)
+			return
+		}
+	}
+
 	respondWithJSON(w, http.StatusAccepted, response{
-		User: User{
-			ID:        user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email:     user.Email,
-			Username:  user.Username,
-			IsPremium: user.IsPremium,
-		},
+		Me: databaseUserToMe(user),
 	})
 }
 
@@ -215,22 +277,99 @@ func (cfg *apiConfig) handlerGetUserByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, User{
-		ID:        userID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email:     user.Email,
-		Username:  user.Username,
-		IsPremium: user.IsPremium,
-	})
+	respondWithJSON(w, http.StatusOK, databaseUserToUser(user))
 }
 
 func (cfg *apiConfig) handlerListAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := cfg.db.ListAllUsers(r.Context())
+	type listedUser struct {
+		ID        uuid.UUID `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		Email     string    `json:"email"`
+		Username  string    `json:"username"`
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't list all users", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusAccepted, users)
+	args := database.ListUsersDescParams{
+		CursorCreatedAt: page.CursorCreatedAt(),
+		CursorID:        page.CursorID(),
+		Limit:           page.FetchLimit(),
+	}
+
+	var users []listedUser
+	if page.Descending() {
+		rows, err := cfg.db.ListUsersDesc(r.Context(), args)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list all users", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, listedUser(row))
+		}
+	} else {
+		rows, err := cfg.db.ListUsersAsc(r.Context(), database.ListUsersAscParams(args))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "can't list all users", err)
+			return
+		}
+		for _, row := range rows {
+			users = append(users, listedUser(row))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewPage(users, page, func(user listedUser) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}))
+}
+
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+	type response struct {
+		User
+	}
+
+	userIDString := r.PathValue("user_id")
+	userID, err := uuid.Parse(userIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't parse user id - handlerSetUserRole", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "can't decode body - handlerSetUserRole", err)
+		return
+	}
+
+	role, err := auth.ParseRole(params.Role)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "role must be user, moderator or admin - handlerSetUserRole", err)
+		return
+	}
+
+	user, err := cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		Role: string(role),
+		ID:   userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "can't find user - handlerSetUserRole", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "can't set user role - handlerSetUserRole", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		User: databaseUserToUser(user),
+	})
 }
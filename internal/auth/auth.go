@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,7 +12,6 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type TokenType string
@@ -19,63 +19,141 @@ type TokenType string
 const (
 	// TokenTypeAccess -
 	TokenTypeAccess TokenType = "media-access"
+	// TokenTypeMFA - proves the password was correct, only exchangeable for tokens together with a second factor
+	TokenTypeMFA TokenType = "media-mfa"
 )
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	if err != nil {
-		return "", err
-	}
-	return string(bytes), err
+// Claims - data carried by an access token. Tokens issued to a third-party
+// client carry its ID and are limited to the scopes the user granted.
+type Claims struct {
+	UserID    uuid.UUID
+	Role      Role
+	SessionID uuid.UUID
+	ClientID  string
+	Scopes    []Scope
+	// IssuedAt and ExpiresAt are filled in by ValidateJWT
+	IssuedAt  time.Time
+	ExpiresAt time.Time
 }
 
-func CheckPasswordHash(password, hash string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Role      Role   `json:"role"`
+	SessionID string `json:"sid"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
 }
 
+// SessionChecker - returns an error if the session an access token was
+// issued for is no longer active
+type SessionChecker func(sessionID uuid.UUID) error
+
 // Generates Token -
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	signingKey := []byte(tokenSecret)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
+func MakeJWT(claims Claims, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return keys.sign(tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    string(TokenTypeAccess),
+			Audience:  jwt.ClaimStrings{keys.audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   claims.UserID.String(),
+		},
+		Role:      claims.Role,
+		SessionID: claims.SessionID.String(),
+		ClientID:  claims.ClientID,
+		Scope:     FormatScopes(claims.Scopes),
 	})
-	return token.SignedString(signingKey)
 }
 
-// Validate Token -
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claimsStruct := jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&claimsStruct,
-		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
-	)
+// Validate Token - checkSession may be nil to skip the session lookup
+func ValidateJWT(tokenString string, keys *KeySet, checkSession SessionChecker) (Claims, error) {
+	claimsStruct := tokenClaims{}
+	token, err := keys.parse(tokenString, &claimsStruct)
 	if err != nil {
-		return uuid.Nil, errors.New("no auth header included in request")
+		return Claims{}, errors.New("no auth header included in request")
 	}
 
 	userIDString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, errors.New("no auth header included in request")
+		return Claims{}, errors.New("no auth header included in request")
 	}
 
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, errors.New("no auth header included in request")
+		return Claims{}, errors.New("no auth header included in request")
 	}
 	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+		return Claims{}, errors.New("invalid issuer")
 	}
 
 	id, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return Claims{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	role, err := ParseRole(string(claimsStruct.Role))
+	if err != nil {
+		return Claims{}, fmt.Errorf("invalid role: %w", err)
+	}
+
+	sessionID, err := uuid.Parse(claimsStruct.SessionID)
+	if err != nil {
+		return Claims{}, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	if checkSession != nil {
+		if err := checkSession(sessionID); err != nil {
+			return Claims{}, fmt.Errorf("session is not active: %w", err)
+		}
+	}
+
+	var scopes []Scope
+	for _, scope := range strings.Fields(claimsStruct.Scope) {
+		scopes = append(scopes, Scope(scope))
+	}
+
+	claims := Claims{
+		UserID:    id,
+		Role:      role,
+		SessionID: sessionID,
+		ClientID:  claimsStruct.ClientID,
+		Scopes:    scopes,
+	}
+	if claimsStruct.IssuedAt != nil {
+		claims.IssuedAt = claimsStruct.IssuedAt.Time
+	}
+	if claimsStruct.ExpiresAt != nil {
+		claims.ExpiresAt = claimsStruct.ExpiresAt.Time
 	}
+	return claims, nil
+}
 
+// MakeMFAToken - short lived challenge issued after the password check of a user with MFA enabled
+func MakeMFAToken(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return keys.sign(jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeMFA),
+		Audience:  jwt.ClaimStrings{keys.audience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+		Subject:   userID.String(),
+	})
+}
+
+// ValidateMFAToken - returns the user the challenge was issued for
+func ValidateMFAToken(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claimsStruct := jwt.RegisteredClaims{}
+	_, err := keys.parse(tokenString, &claimsStruct)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid mfa token: %w", err)
+	}
+	if claimsStruct.Issuer != string(TokenTypeMFA) {
+		return uuid.Nil, errors.New("invalid issuer")
+	}
+
+	id, err := uuid.Parse(claimsStruct.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+	}
 	return id, nil
 }
 
@@ -89,6 +167,13 @@ func MakeRefreshToken() (string, error) {
 
 }
 
+// HashToken - hashes a random token before it is stored, so a database leak
+// doesn't leak usable tokens. Tokens already have enough entropy for SHA-256.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetHeaderValues
 func GetBearerToken(header http.Header) (string, error) {
 	authHeader := header.Get("Authorization")
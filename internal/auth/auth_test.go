@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -12,8 +13,8 @@ func TestCheckPasswordHash(t *testing.T) {
 	// First, we need to create some hashed passwords for testing
 	password1 := "correctPassword123!"
 	password2 := "anotherPassword456!"
-	hash1, _ := HashPassword(password1)
-	hash2, _ := HashPassword(password2)
+	hash1, _ := DefaultHasher.Hash(password1)
+	hash2, _ := DefaultHasher.Hash(password2)
 
 	tests := []struct {
 		name     string
@@ -63,15 +64,35 @@ func TestCheckPasswordHash(t *testing.T) {
 	}
 }
 
+func testKeySet(t *testing.T, secret string) *KeySet {
+	t.Helper()
+	keys, err := NewHMACKeySet(secret, "test-audience")
+	if err != nil {
+		t.Fatalf("NewHMACKeySet() error = %v", err)
+	}
+	return keys
+}
+
 func TestValidateJWT(t *testing.T) {
+	keys := testKeySet(t, "secret")
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	sessionID := uuid.New()
+	revokedSessionID := uuid.New()
+	validToken, _ := MakeJWT(Claims{UserID: userID, Role: RoleModerator, SessionID: sessionID}, keys, time.Hour)
+	revokedToken, _ := MakeJWT(Claims{UserID: userID, Role: RoleModerator, SessionID: revokedSessionID}, keys, time.Hour)
+	checkSession := func(id uuid.UUID) error {
+		if id == revokedSessionID {
+			return errors.New("session revoked")
+		}
+		return nil
+	}
 
 	tests := []struct {
 		name        string
 		tokenString string
 		tokenSecret string
 		wantUserID  uuid.UUID
+		wantRole    Role
 		wantErr     bool
 	}{
 		{
@@ -79,6 +100,7 @@ func TestValidateJWT(t *testing.T) {
 			tokenString: validToken,
 			tokenSecret: "secret",
 			wantUserID:  userID,
+			wantRole:    RoleModerator,
 			wantErr:     false,
 		},
 		{
@@ -95,22 +117,72 @@ func TestValidateJWT(t *testing.T) {
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
+		{
+			name:        "Revoked session",
+			tokenString: revokedToken,
+			tokenSecret: "secret",
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotClaims, err := ValidateJWT(tt.tokenString, testKeySet(t, tt.tokenSecret), checkSession)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if gotUserID != tt.wantUserID {
-				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, tt.wantUserID)
+			if gotClaims.UserID != tt.wantUserID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotClaims.UserID, tt.wantUserID)
+			}
+			if gotClaims.Role != tt.wantRole {
+				t.Errorf("ValidateJWT() gotRole = %v, want %v", gotClaims.Role, tt.wantRole)
 			}
 		})
 	}
 }
 
+func TestValidateJWTClientScopes(t *testing.T) {
+	keys := testKeySet(t, "secret")
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	token, err := MakeJWT(Claims{
+		UserID:    userID,
+		Role:      RoleUser,
+		SessionID: sessionID,
+		ClientID:  "client-1",
+		Scopes:    []Scope{ScopePostsRead, ScopeLikesWrite},
+	}, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+
+	claims, err := ValidateJWT(token, keys, nil)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if claims.ClientID != "client-1" {
+		t.Errorf("ValidateJWT() ClientID = %v, want client-1", claims.ClientID)
+	}
+	if FormatScopes(claims.Scopes) != "posts:read likes:write" {
+		t.Errorf("ValidateJWT() Scopes = %v, want posts:read likes:write", claims.Scopes)
+	}
+	if claims.ExpiresAt.Before(time.Now()) || claims.IssuedAt.After(time.Now()) {
+		t.Errorf("ValidateJWT() IssuedAt = %v, ExpiresAt = %v", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	firstParty, _ := MakeJWT(Claims{UserID: userID, Role: RoleUser, SessionID: sessionID}, keys, time.Hour)
+	claims, err = ValidateJWT(firstParty, keys, nil)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if claims.ClientID != "" || claims.Scopes != nil {
+		t.Errorf("ValidateJWT() first-party token has client %q and scopes %v", claims.ClientID, claims.Scopes)
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -155,3 +227,73 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestHashToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("MakeRefreshToken() error = %v", err)
+	}
+
+	hash := HashToken(token)
+	if hash == token {
+		t.Errorf("HashToken() returned the token unchanged")
+	}
+	if HashToken(token) != hash {
+		t.Errorf("HashToken() is not deterministic")
+	}
+	if HashToken(token+"x") == hash {
+		t.Errorf("HashToken() returned the same hash for different tokens")
+	}
+}
+
+func TestValidateMFAToken(t *testing.T) {
+	keys := testKeySet(t, "secret")
+	userID := uuid.New()
+	mfaToken, _ := MakeMFAToken(userID, keys, time.Minute)
+	expiredToken, _ := MakeMFAToken(userID, keys, -time.Minute)
+	accessToken, _ := MakeJWT(Claims{UserID: userID, Role: RoleUser, SessionID: uuid.New()}, keys, time.Hour)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		wantUserID  uuid.UUID
+		wantErr     bool
+	}{
+		{
+			name:        "Valid mfa token",
+			tokenString: mfaToken,
+			wantUserID:  userID,
+			wantErr:     false,
+		},
+		{
+			name:        "Expired mfa token",
+			tokenString: expiredToken,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Access token is not an mfa token",
+			tokenString: accessToken,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, err := ValidateMFAToken(tt.tokenString, keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMFAToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("ValidateMFAToken() gotUserID = %v, want %v", gotUserID, tt.wantUserID)
+			}
+		})
+	}
+
+	// An mfa token must never be accepted where an access token is expected
+	if _, err := ValidateJWT(mfaToken, keys, nil); err == nil {
+		t.Errorf("ValidateJWT() accepted an mfa token")
+	}
+}
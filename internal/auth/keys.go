@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// hmacKeyID - kid of the symmetric key, HMAC keys are never published in the JWKS
+const hmacKeyID = "hmac"
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet - the key used to sign new tokens plus every key tokens are still
+// accepted from, so keys can be rotated without logging everybody out
+type KeySet struct {
+	audience string
+	current  *signingKey
+	keys     map[string]*signingKey
+}
+
+// NewHMACKeySet - HS256 key set, other services can't verify these tokens
+// without knowing the secret
+func NewHMACKeySet(secret, audience string) (*KeySet, error) {
+	if secret == "" {
+		return nil, errors.New("empty hmac secret")
+	}
+	key := &signingKey{
+		id:        hmacKeyID,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+	return newKeySet(key, audience)
+}
+
+// NewKeySet - key set that signs with an RSA (RS256) or Ed25519 (EdDSA) private key
+func NewKeySet(signer crypto.Signer, audience string) (*KeySet, error) {
+	key, err := newAsymmetricKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.signKey = signer
+	return newKeySet(key, audience)
+}
+
+func newKeySet(current *signingKey, audience string) (*KeySet, error) {
+	if audience == "" {
+		return nil, errors.New("empty audience")
+	}
+	return &KeySet{
+		audience: audience,
+		current:  current,
+		keys:     map[string]*signingKey{current.id: current},
+	}, nil
+}
+
+// AddVerificationKey - keeps accepting tokens signed by a previous key
+func (ks *KeySet) AddVerificationKey(public crypto.PublicKey) error {
+	key, err := newAsymmetricKey(public)
+	if err != nil {
+		return err
+	}
+	ks.keys[key.id] = key
+	return nil
+}
+
+func newAsymmetricKey(public crypto.PublicKey) (*signingKey, error) {
+	var method jwt.SigningMethod
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("rsa keys must have at least 2048 bits")
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	jwk, err := publicJWK(public, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		id:        jwk.thumbprint(),
+		method:    method,
+		verifyKey: public,
+	}, nil
+}
+
+// sign - signs claims with the current key and names it in the kid header
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.current.method, claims)
+	token.Header["kid"] = ks.current.id
+	return token.SignedString(ks.current.signKey)
+}
+
+// parse - verifies the token with the key named in its kid header, the
+// algorithm has to be the one that key signs with
+func (ks *KeySet) parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		key := ks.current
+		if kid, ok := token.Header["kid"].(string); ok {
+			key, ok = ks.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown key id %q", kid)
+			}
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing algorithm %q", token.Method.Alg())
+		}
+		return key.verifyKey, nil
+	}
+
+	return jwt.ParseWithClaims(
+		tokenString,
+		claims,
+		keyFunc,
+		jwt.WithAudience(ks.audience),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+// JWK - public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS - public keys that verify our tokens, served to other services
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if key.id == hmacKeyID {
+			continue
+		}
+		jwk, err := publicJWK(key.verifyKey, key.id, key.method.Alg())
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+	return jwks
+}
+
+func publicJWK(public crypto.PublicKey, kid, alg string) (JWK, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: alg,
+			N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType:   "OKP",
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: alg,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	}
+	return JWK{}, fmt.Errorf("unsupported key type %T", public)
+}
+
+// PublicKey - decodes the key, used for JWKS published by other services.
+// Supports RSA, P-256 and Ed25519 keys.
+func (jwk JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return public, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+}
+
+// thumbprint - RFC 7638 thumbprint, used as the kid so it never has to be configured
+func (jwk JWK) thumbprint() string {
+	var members interface{}
+	switch jwk.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ParsePrivateKeyPEM - reads a PKCS #8 (or PKCS #1 RSA) private key
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// ParsePublicKeyPEM - reads a PKIX public key
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse public key: %w", err)
+	}
+	return key, nil
+}
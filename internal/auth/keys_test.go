@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestKeySetRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("can't generate rsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("can't generate ed25519 key: %v", err)
+	}
+
+	oldKeys, err := NewKeySet(rsaKey, "test-audience")
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	newKeys, err := NewKeySet(edKey, "test-audience")
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	if err := newKeys.AddVerificationKey(rsaKey.Public()); err != nil {
+		t.Fatalf("AddVerificationKey() error = %v", err)
+	}
+	otherAudience, err := NewKeySet(edKey, "other-audience")
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	claims := Claims{UserID: uuid.New(), Role: RoleUser, SessionID: uuid.New()}
+	oldToken, _ := MakeJWT(claims, oldKeys, time.Hour)
+	newToken, _ := MakeJWT(claims, newKeys, time.Hour)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		keys        *KeySet
+		wantErr     bool
+	}{
+		{
+			name:        "RS256 token verified by its own key set",
+			tokenString: oldToken,
+			keys:        oldKeys,
+			wantErr:     false,
+		},
+		{
+			name:        "EdDSA token verified by its own key set",
+			tokenString: newToken,
+			keys:        newKeys,
+			wantErr:     false,
+		},
+		{
+			name:        "Token of rotated out key still accepted",
+			tokenString: oldToken,
+			keys:        newKeys,
+			wantErr:     false,
+		},
+		{
+			name:        "Token of unknown key rejected",
+			tokenString: newToken,
+			keys:        oldKeys,
+			wantErr:     true,
+		},
+		{
+			name:        "Token for other audience rejected",
+			tokenString: newToken,
+			keys:        otherAudience,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateJWT(tt.tokenString, tt.keys, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.UserID != claims.UserID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", got.UserID, claims.UserID)
+			}
+		})
+	}
+}
+
+// A token signed with HS256 using the public key as secret must not pass
+// as a token of the RSA key
+func TestKeySetRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("can't generate rsa key: %v", err)
+	}
+	keys, err := NewKeySet(rsaKey, "test-audience")
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	publicDER, _ := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    string(TokenTypeAccess),
+			Audience:  jwt.ClaimStrings{"test-audience"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Subject:   uuid.NewString(),
+		},
+		Role:      RoleAdmin,
+		SessionID: uuid.NewString(),
+	})
+	forged.Header["kid"] = keys.current.id
+	forgedString, err := forged.SignedString(publicPEM)
+	if err != nil {
+		t.Fatalf("can't sign forged token: %v", err)
+	}
+
+	if _, err := ValidateJWT(forgedString, keys, nil); err == nil {
+		t.Errorf("ValidateJWT() accepted a token with the wrong algorithm")
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	keys, _ := NewKeySet(edKey, "test-audience")
+	_ = keys.AddVerificationKey(rsaKey.Public())
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2", len(jwks.Keys))
+	}
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyID == "" || jwk.Use != "sig" {
+			t.Errorf("JWKS() key %+v is missing kid or use", jwk)
+		}
+		if jwk.KeyType == "OKP" && jwk.KeyID != keys.current.id {
+			t.Errorf("JWKS() Ed25519 kid = %v, want %v", jwk.KeyID, keys.current.id)
+		}
+	}
+
+	hmacKeys := testKeySet(t, "secret")
+	if got := len(hmacKeys.JWKS().Keys); got != 0 {
+		t.Errorf("JWKS() published %d hmac keys, want 0", got)
+	}
+}
+
+func TestParsePEMKeys(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	signer, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyPEM() error = %v", err)
+	}
+	if !edKey.Equal(signer) {
+		t.Errorf("ParsePrivateKeyPEM() returned a different key")
+	}
+
+	publicDER, _ := x509.MarshalPKIXPublicKey(edKey.Public())
+	public, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil {
+		t.Fatalf("ParsePublicKeyPEM() error = %v", err)
+	}
+	if !edKey.Public().(ed25519.PublicKey).Equal(public) {
+		t.Errorf("ParsePublicKeyPEM() returned a different key")
+	}
+
+	if _, err := ParsePrivateKeyPEM([]byte("not a key")); err == nil {
+		t.Errorf("ParsePrivateKeyPEM() accepted garbage")
+	}
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	edJWK, _ := publicJWK(edKey.Public(), "", "")
+	rsaJWK, _ := publicJWK(rsaKey.Public(), "", "")
+	ecJWK := JWK{
+		KeyType: "EC",
+		Curve:   "P-256",
+		X:       base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		Y:       base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+	}
+
+	tests := []struct {
+		name    string
+		jwk     JWK
+		want    interface{ Equal(crypto.PublicKey) bool }
+		wantErr bool
+	}{
+		{
+			name: "Ed25519",
+			jwk:  edJWK,
+			want: edKey.Public().(ed25519.PublicKey),
+		},
+		{
+			name: "RSA",
+			jwk:  rsaJWK,
+			want: &rsaKey.PublicKey,
+		},
+		{
+			name: "P-256",
+			jwk:  ecJWK,
+			want: &ecKey.PublicKey,
+		},
+		{
+			name:    "Point not on the curve",
+			jwk:     JWK{KeyType: "EC", Curve: "P-256", X: ecJWK.X, Y: ecJWK.X},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown key type",
+			jwk:     JWK{KeyType: "oct"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.jwk.PublicKey()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.want.Equal(got) {
+				t.Errorf("PublicKey() returned a different key")
+			}
+		})
+	}
+}
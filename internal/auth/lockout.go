@@ -0,0 +1,29 @@
+package auth
+
+import "time"
+
+// LockoutPolicy - how long logins are blocked after repeated failures.
+// Starting at Threshold failures the lock doubles with every failure, up to MaxDelay.
+type LockoutPolicy struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// ResetAfter - failures older than this are forgotten
+	ResetAfter time.Duration
+}
+
+// LockDuration - lock to apply after the given number of consecutive failures
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.Threshold; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
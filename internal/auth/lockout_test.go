@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyLockDuration(t *testing.T) {
+	policy := LockoutPolicy{
+		Threshold:  5,
+		BaseDelay:  30 * time.Second,
+		MaxDelay:   5 * time.Minute,
+		ResetAfter: 15 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "No failures", failures: 0, want: 0},
+		{name: "Below threshold", failures: 4, want: 0},
+		{name: "At threshold", failures: 5, want: 30 * time.Second},
+		{name: "Doubles after threshold", failures: 6, want: time.Minute},
+		{name: "Keeps doubling", failures: 8, want: 4 * time.Minute},
+		{name: "Capped at max", failures: 9, want: 5 * time.Minute},
+		{name: "Stays capped", failures: 100, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.LockDuration(tt.failures); got != tt.want {
+				t.Errorf("LockDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ParseScopeParam - parses the space separated scope parameter of OAuth requests
+func ParseScopeParam(scope string) ([]Scope, error) {
+	return ParseScopes(strings.Fields(scope))
+}
+
+// FormatScopes - space separated scope list, as used in tokens and OAuth responses
+func FormatScopes(scopes []Scope) string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, string(scope))
+	}
+	return strings.Join(names, " ")
+}
+
+// VerifyCodeChallenge - checks a PKCE code verifier against the S256
+// challenge sent with the authorization request (RFC 7636)
+func VerifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		if !isUnreserved(c) {
+			return false
+		}
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func isUnreserved(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// ValidateRedirectURI - redirect URIs of OAuth clients must be absolute,
+// without fragment, and use https unless they point at the loopback interface
+func ValidateRedirectURI(uri string) error {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("invalid redirect uri: %w", err)
+	}
+	if !parsed.IsAbs() || parsed.Host == "" {
+		return errors.New("redirect uri must be absolute")
+	}
+	if parsed.Fragment != "" || strings.Contains(uri, "#") {
+		return errors.New("redirect uri must not have a fragment")
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := parsed.Hostname()
+		if host == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return errors.New("redirect uri must use https")
+	}
+	return fmt.Errorf("unsupported redirect uri scheme %q", parsed.Scheme)
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	verifier := strings.Repeat("a1-._~", 8)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{
+			name:      "Matching verifier",
+			verifier:  verifier,
+			challenge: challenge,
+			want:      true,
+		},
+		{
+			name:      "Other verifier",
+			verifier:  strings.Repeat("b", 43),
+			challenge: challenge,
+			want:      false,
+		},
+		{
+			name:      "Verifier too short",
+			verifier:  "short",
+			challenge: challenge,
+			want:      false,
+		},
+		{
+			name:      "Plain challenge isn't accepted",
+			verifier:  verifier,
+			challenge: verifier,
+			want:      false,
+		},
+		{
+			name:      "Invalid characters",
+			verifier:  strings.Repeat("a", 42) + "+",
+			challenge: challenge,
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyCodeChallenge(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("VerifyCodeChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRedirectURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		wantErr bool
+	}{
+		{
+			name:    "Https",
+			uri:     "https://app.example.com/callback",
+			wantErr: false,
+		},
+		{
+			name:    "Loopback http",
+			uri:     "http://127.0.0.1:5000/callback",
+			wantErr: false,
+		},
+		{
+			name:    "Localhost http",
+			uri:     "http://localhost:3000/callback",
+			wantErr: false,
+		},
+		{
+			name:    "Remote http",
+			uri:     "http://app.example.com/callback",
+			wantErr: true,
+		},
+		{
+			name:    "Fragment",
+			uri:     "https://app.example.com/callback#token",
+			wantErr: true,
+		},
+		{
+			name:    "Relative",
+			uri:     "/callback",
+			wantErr: true,
+		},
+		{
+			name:    "Javascript",
+			uri:     "javascript:alert(1)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRedirectURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRedirectURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScopeParam(t *testing.T) {
+	scopes, err := ParseScopeParam("posts:read  likes:write")
+	if err != nil {
+		t.Fatalf("ParseScopeParam() error = %v", err)
+	}
+	if got := FormatScopes(scopes); got != "posts:read likes:write" {
+		t.Errorf("FormatScopes() = %q, want %q", got, "posts:read likes:write")
+	}
+
+	if _, err := ParseScopeParam("posts:read openid"); err == nil {
+		t.Errorf("ParseScopeParam() accepted an unknown scope")
+	}
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher - hashes new passwords with one algorithm and set of parameters.
+// Hashes record their algorithm and parameters, so CheckPasswordHash can
+// verify them whatever the current hasher is.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash - reports whether hash was made with another algorithm or other parameters
+	NeedsRehash(hash string) bool
+	// MaxPasswordLength - the longest password in bytes the algorithm takes into account
+	MaxPasswordLength() int
+}
+
+// DefaultHasher - used when nothing else is configured
+var DefaultHasher Hasher = BcryptHasher{Cost: 14}
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.Cost
+}
+
+// MaxPasswordLength - bcrypt ignores everything after 72 bytes
+func (h BcryptHasher) MaxPasswordLength() int {
+	return 72
+}
+
+// Argon2idHasher - Memory is in KiB, encodes hashes in the PHC string format
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+const (
+	argon2idPrefix    = "$argon2id$"
+	argon2idSaltLen   = 16
+	argon2idKeyLength = 32
+)
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, argon2idKeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Memory,
+		h.Iterations,
+		h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params != h
+}
+
+// MaxPasswordLength - argon2id reads the whole password, the limit only keeps
+// requests from hashing megabytes
+func (h Argon2idHasher) MaxPasswordLength() int {
+	return 1024
+}
+
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idHasher{}, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Argon2idHasher{}, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	params := Argon2idHasher{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, fmt.Errorf("invalid argon2 key: %w", err)
+	}
+	return params, salt, key, nil
+}
+
+// CheckPasswordHash - verifies a bcrypt or argon2id hash
+func CheckPasswordHash(password, hash string) error {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	// #nosec G115 -- the key length comes from our own encoded hashes
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return errors.New("password doesn't match hash")
+	}
+	return nil
+}
+
+var dummyHashes sync.Map
+
+// CheckPasswordDummy - takes as long as CheckPasswordHash with a hash of the
+// hasher, so the response time doesn't tell whether an account exists
+func CheckPasswordDummy(hasher Hasher, password string) {
+	hash, ok := dummyHashes.Load(hasher)
+	if !ok {
+		newHash, err := hasher.Hash("dummy-password-for-unknown-users")
+		if err != nil {
+			return
+		}
+		hash, _ = dummyHashes.LoadOrStore(hasher, newHash)
+	}
+	_ = CheckPasswordHash(password, hash.(string))
+}
+
+// PasswordPolicy - rules a new password has to follow
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// Common - lowercased passwords that are known from breaches or too common to be safe
+	Common map[string]struct{}
+}
+
+// DefaultPasswordPolicy - MaxLength is taken from the hasher in use, see
+// PolicyFor
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength: 8,
+}
+
+// PolicyFor - the policy with the longest password the hasher takes into
+// account as MaxLength, so no part of a password is silently ignored
+func (p PasswordPolicy) PolicyFor(hasher Hasher) PasswordPolicy {
+	p.MaxLength = hasher.MaxPasswordLength()
+	return p
+}
+
+func (p PasswordPolicy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return fmt.Errorf("password must be at most %d bytes long", p.MaxLength)
+	}
+	if _, ok := p.Common[strings.ToLower(password)]; ok {
+		return errors.New("password is too common, choose another one")
+	}
+	return nil
+}
+
+// LoadCommonPasswords - reads a password list with one password per line,
+// empty lines and lines starting with # are skipped
+func LoadCommonPasswords(r io.Reader) (map[string]struct{}, error) {
+	common := map[string]struct{}{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		common[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return common, nil
+}
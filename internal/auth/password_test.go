@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+var testArgon2id = Argon2idHasher{Memory: 8 * 1024, Iterations: 1, Parallelism: 1}
+
+func TestCheckPasswordHashFormats(t *testing.T) {
+	bcryptHash, _ := BcryptHasher{Cost: 4}.Hash("correctPassword123!")
+	argonHash, _ := testArgon2id.Hash("correctPassword123!")
+
+	if !strings.HasPrefix(argonHash, "$argon2id$v=19$m=8192,t=1,p=1$") {
+		t.Errorf("Argon2idHasher.Hash() = %v, doesn't record its parameters", argonHash)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		wantErr  bool
+	}{
+		{name: "Correct bcrypt password", password: "correctPassword123!", hash: bcryptHash, wantErr: false},
+		{name: "Wrong bcrypt password", password: "wrongPassword", hash: bcryptHash, wantErr: true},
+		{name: "Correct argon2id password", password: "correctPassword123!", hash: argonHash, wantErr: false},
+		{name: "Wrong argon2id password", password: "wrongPassword", hash: argonHash, wantErr: true},
+		{name: "Malformed argon2id hash", password: "correctPassword123!", hash: "$argon2id$v=19$broken", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPasswordHash(tt.password, tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPasswordHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcrypt4, _ := BcryptHasher{Cost: 4}.Hash("password")
+	argonHash, _ := testArgon2id.Hash("password")
+
+	tests := []struct {
+		name   string
+		hasher Hasher
+		hash   string
+		want   bool
+	}{
+		{name: "Same bcrypt cost", hasher: BcryptHasher{Cost: 4}, hash: bcrypt4, want: false},
+		{name: "Higher bcrypt cost", hasher: BcryptHasher{Cost: 5}, hash: bcrypt4, want: true},
+		{name: "Bcrypt to argon2id", hasher: testArgon2id, hash: bcrypt4, want: true},
+		{name: "Same argon2id parameters", hasher: testArgon2id, hash: argonHash, want: false},
+		{name: "More argon2id memory", hasher: Argon2idHasher{Memory: 16 * 1024, Iterations: 1, Parallelism: 1}, hash: argonHash, want: true},
+		{name: "Argon2id to bcrypt", hasher: BcryptHasher{Cost: 4}, hash: argonHash, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	common, err := LoadCommonPasswords(strings.NewReader("# common passwords\npassword123\n\nQwertyuiop\n"))
+	if err != nil {
+		t.Fatalf("LoadCommonPasswords() error = %v", err)
+	}
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 72, Common: common}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "Good password", password: "correct horse battery", wantErr: false},
+		{name: "Too short", password: "short", wantErr: true},
+		{name: "Too long", password: strings.Repeat("a", 73), wantErr: true},
+		{name: "Common password", password: "password123", wantErr: true},
+		{name: "Common password in other case", password: "qwertyUIOP", wantErr: true},
+		{name: "Multibyte characters count as one", password: "ünïcödé", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyFor(t *testing.T) {
+	password := strings.Repeat("a", 100)
+
+	err := DefaultPasswordPolicy.PolicyFor(BcryptHasher{Cost: 4}).Validate(password)
+	if err == nil {
+		t.Errorf("Validate() with bcrypt accepted a password longer than 72 bytes")
+	}
+	err = DefaultPasswordPolicy.PolicyFor(testArgon2id).Validate(password)
+	if err != nil {
+		t.Errorf("Validate() with argon2id error = %v", err)
+	}
+}
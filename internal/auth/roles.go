@@ -0,0 +1,36 @@
+package auth
+
+import "fmt"
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// roleRank orders roles so that higher roles inherit lower ones
+var roleRank = map[Role]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ParseRole - checks that role is one of the known roles
+func ParseRole(role string) (Role, error) {
+	r := Role(role)
+	if _, ok := roleRank[r]; !ok {
+		return "", fmt.Errorf("unknown role: %q", role)
+	}
+	return r, nil
+}
+
+// Allows - reports whether r grants at least the privileges of required
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRank[r]
+	if !ok {
+		return false
+	}
+	return rank >= roleRank[required]
+}
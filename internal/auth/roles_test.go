@@ -0,0 +1,92 @@
+package auth
+
+import "testing"
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     Role
+		required Role
+		want     bool
+	}{
+		{
+			name:     "Admin allowed on moderator route",
+			role:     RoleAdmin,
+			required: RoleModerator,
+			want:     true,
+		},
+		{
+			name:     "Moderator allowed on moderator route",
+			role:     RoleModerator,
+			required: RoleModerator,
+			want:     true,
+		},
+		{
+			name:     "User denied on moderator route",
+			role:     RoleUser,
+			required: RoleModerator,
+			want:     false,
+		},
+		{
+			name:     "Moderator denied on admin route",
+			role:     RoleModerator,
+			required: RoleAdmin,
+			want:     false,
+		},
+		{
+			name:     "Unknown role denied",
+			role:     Role("root"),
+			required: RoleUser,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.role.Allows(tt.required); got != tt.want {
+				t.Errorf("Role.Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		want    Role
+		wantErr bool
+	}{
+		{
+			name:    "Valid role",
+			role:    "admin",
+			want:    RoleAdmin,
+			wantErr: false,
+		},
+		{
+			name:    "Unknown role",
+			role:    "superuser",
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "Empty role",
+			role:    "",
+			want:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRole(tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope - permission granted to an API key
+type Scope string
+
+const (
+	ScopePostsRead    Scope = "posts:read"
+	ScopePostsWrite   Scope = "posts:write"
+	ScopeLikesWrite   Scope = "likes:write"
+	ScopeReportsWrite Scope = "reports:write"
+)
+
+var knownScopes = map[Scope]bool{
+	ScopePostsRead:    true,
+	ScopePostsWrite:   true,
+	ScopeLikesWrite:   true,
+	ScopeReportsWrite: true,
+}
+
+const apiKeyPrefix = "smk_"
+
+// ParseScopes - checks every scope is known and drops duplicates
+func ParseScopes(scopes []string) ([]Scope, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	seen := map[Scope]bool{}
+	parsed := make([]Scope, 0, len(scopes))
+	for _, s := range scopes {
+		scope := Scope(s)
+		if !knownScopes[scope] {
+			return nil, fmt.Errorf("unknown scope: %q", s)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		parsed = append(parsed, scope)
+	}
+	return parsed, nil
+}
+
+// HasScope - reports whether required is one of scopes
+func HasScope(scopes []Scope, required Scope) bool {
+	for _, scope := range scopes {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeAPIKey - random key with a recognizable prefix, so leaked keys are easy to spot in code and logs
+func MakeAPIKey() (string, error) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + token, nil
+}
+
+// APIKeyDisplayPrefix - start of the key that is stored in plain text so users can tell their keys apart
+func APIKeyDisplayPrefix(key string) string {
+	if !strings.HasPrefix(key, apiKeyPrefix) || len(key) < len(apiKeyPrefix)+8 {
+		return ""
+	}
+	return key[:len(apiKeyPrefix)+8]
+}
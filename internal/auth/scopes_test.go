@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []Scope
+		wantErr bool
+	}{
+		{
+			name:    "Known scopes",
+			scopes:  []string{"posts:read", "posts:write"},
+			want:    []Scope{ScopePostsRead, ScopePostsWrite},
+			wantErr: false,
+		},
+		{
+			name:    "Duplicates are dropped",
+			scopes:  []string{"likes:write", "likes:write"},
+			want:    []Scope{ScopeLikesWrite},
+			wantErr: false,
+		},
+		{
+			name:    "Unknown scope",
+			scopes:  []string{"posts:read", "admin"},
+			wantErr: true,
+		},
+		{
+			name:    "No scopes",
+			scopes:  nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScopes(tt.scopes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseScopes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseScopes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseScopes() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	scopes := []Scope{ScopePostsRead, ScopeLikesWrite}
+
+	if !HasScope(scopes, ScopeLikesWrite) {
+		t.Errorf("HasScope() = false for a granted scope")
+	}
+	if HasScope(scopes, ScopePostsWrite) {
+		t.Errorf("HasScope() = true for a scope that wasn't granted")
+	}
+}
+
+func TestMakeAPIKey(t *testing.T) {
+	key, err := MakeAPIKey()
+	if err != nil {
+		t.Fatalf("MakeAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(key, "smk_") {
+		t.Errorf("MakeAPIKey() = %v, missing prefix", key)
+	}
+
+	prefix := APIKeyDisplayPrefix(key)
+	if !strings.HasPrefix(key, prefix) || len(prefix) != 12 {
+		t.Errorf("APIKeyDisplayPrefix() = %v, want the first 12 characters of %v", prefix, key)
+	}
+	if APIKeyDisplayPrefix("not-a-key") != "" {
+		t.Errorf("APIKeyDisplayPrefix() returned a prefix for a foreign key")
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 6238 appendix B, truncated to 6 digits
+func TestGenerateTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		name string
+		time int64
+		want string
+	}{
+		{name: "T=59", time: 59, want: "287082"},
+		{name: "T=1111111109", time: 1111111109, want: "081804"},
+		{name: "T=1111111111", time: 1111111111, want: "050471"},
+		{name: "T=1234567890", time: 1234567890, want: "005924"},
+		{name: "T=2000000000", time: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateTOTPCode(secret, TOTPStep(time.Unix(tt.time, 0)))
+			if err != nil {
+				t.Fatalf("GenerateTOTPCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateTOTPCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	step := TOTPStep(now)
+
+	current, _ := GenerateTOTPCode(secret, step)
+	previous, _ := GenerateTOTPCode(secret, step-1)
+	tooOld, _ := GenerateTOTPCode(secret, step-2)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantErr  bool
+	}{
+		{name: "Current code", code: current, wantStep: step, wantErr: false},
+		{name: "Previous code within skew", code: previous, wantStep: step - 1, wantErr: false},
+		{name: "Code outside skew", code: tooOld, wantErr: true},
+		{name: "Wrong length", code: "123", wantErr: true},
+		{name: "Empty code", code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, err := ValidateTOTP(secret, tt.code, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTOTP() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() step = %v, want %v", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Social Media", "user@example.com", "JBSWY3DPEHPK3PXP")
+
+	for _, want := range []string{"otpauth://totp/", "secret=JBSWY3DPEHPK3PXP", "issuer=Social+Media", "digits=6", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("TOTPURI() = %v, doesn't contain %v", uri, want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want 10", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if seen[code] {
+			t.Errorf("GenerateRecoveryCodes() returned duplicate code %v", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,316 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: blocks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createBlock = `-- name: CreateBlock :one
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING blocker_id, blocked_id, created_at
+`
+
+type CreateBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) CreateBlock(ctx context.Context, arg CreateBlockParams) (Block, error) {
+	row := q.db.QueryRowContext(ctx, createBlock, arg.BlockerID, arg.BlockedID)
+	var i Block
+	err := row.Scan(&i.BlockerID, &i.BlockedID, &i.CreatedAt)
+	return i, err
+}
+
+const createMute = `-- name: CreateMute :one
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING muter_id, muted_id, created_at
+`
+
+type CreateMuteParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) CreateMute(ctx context.Context, arg CreateMuteParams) (Mute, error) {
+	row := q.db.QueryRowContext(ctx, createMute, arg.MuterID, arg.MutedID)
+	var i Mute
+	err := row.Scan(&i.MuterID, &i.MutedID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteBlock = `-- name: DeleteBlock :one
+DELETE FROM blocks
+WHERE blocker_id = $1
+AND blocked_id = $2
+RETURNING blocker_id, blocked_id, created_at
+`
+
+type DeleteBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) DeleteBlock(ctx context.Context, arg DeleteBlockParams) (Block, error) {
+	row := q.db.QueryRowContext(ctx, deleteBlock, arg.BlockerID, arg.BlockedID)
+	var i Block
+	err := row.Scan(&i.BlockerID, &i.BlockedID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteMute = `-- name: DeleteMute :one
+DELETE FROM mutes
+WHERE muter_id = $1
+AND muted_id = $2
+RETURNING muter_id, muted_id, created_at
+`
+
+type DeleteMuteParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) DeleteMute(ctx context.Context, arg DeleteMuteParams) (Mute, error) {
+	row := q.db.QueryRowContext(ctx, deleteMute, arg.MuterID, arg.MutedID)
+	var i Mute
+	err := row.Scan(&i.MuterID, &i.MutedID, &i.CreatedAt)
+	return i, err
+}
+
+const listBlocksAsc = `-- name: ListBlocksAsc :many
+SELECT users.id, users.username, blocks.created_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = $1
+AND ($2::timestamp IS NULL
+   OR (blocks.created_at, blocks.blocked_id) > ($2::timestamp, $3::uuid))
+ORDER BY blocks.created_at ASC, blocks.blocked_id ASC
+LIMIT $4
+`
+
+type ListBlocksAscParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListBlocksAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListBlocksAsc(ctx context.Context, arg ListBlocksAscParams) ([]ListBlocksAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBlocksAsc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBlocksAscRow
+	for rows.Next() {
+		var i ListBlocksAscRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlocksDesc = `-- name: ListBlocksDesc :many
+SELECT users.id, users.username, blocks.created_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = $1
+AND ($2::timestamp IS NULL
+   OR (blocks.created_at, blocks.blocked_id) < ($2::timestamp, $3::uuid))
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT $4
+`
+
+type ListBlocksDescParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListBlocksDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListBlocksDesc(ctx context.Context, arg ListBlocksDescParams) ([]ListBlocksDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBlocksDesc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBlocksDescRow
+	for rows.Next() {
+		var i ListBlocksDescRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMutesAsc = `-- name: ListMutesAsc :many
+SELECT users.id, users.username, mutes.created_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = $1
+AND ($2::timestamp IS NULL
+   OR (mutes.created_at, mutes.muted_id) > ($2::timestamp, $3::uuid))
+ORDER BY mutes.created_at ASC, mutes.muted_id ASC
+LIMIT $4
+`
+
+type ListMutesAscParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListMutesAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListMutesAsc(ctx context.Context, arg ListMutesAscParams) ([]ListMutesAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMutesAsc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMutesAscRow
+	for rows.Next() {
+		var i ListMutesAscRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMutesDesc = `-- name: ListMutesDesc :many
+SELECT users.id, users.username, mutes.created_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = $1
+AND ($2::timestamp IS NULL
+   OR (mutes.created_at, mutes.muted_id) < ($2::timestamp, $3::uuid))
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT $4
+`
+
+type ListMutesDescParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListMutesDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListMutesDesc(ctx context.Context, arg ListMutesDescParams) ([]ListMutesDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMutesDesc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMutesDescRow
+	for rows.Next() {
+		var i ListMutesDescRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const usersBlocked = `-- name: UsersBlocked :one
+SELECT users_blocked($1::uuid, $2::uuid)::boolean AS blocked
+`
+
+type UsersBlockedParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+func (q *Queries) UsersBlocked(ctx context.Context, arg UsersBlockedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, usersBlocked, arg.UserID, arg.OtherID)
+	var blocked bool
+	err := row.Scan(&blocked)
+	return blocked, err
+}
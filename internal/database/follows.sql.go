@@ -0,0 +1,517 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: follows.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const authorVisible = `-- name: AuthorVisible :one
+SELECT author_visible($1::uuid, $2::uuid)::boolean AS visible
+`
+
+type AuthorVisibleParams struct {
+	AuthorID uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) AuthorVisible(ctx context.Context, arg AuthorVisibleParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, authorVisible, arg.AuthorID, arg.ViewerID)
+	var visible bool
+	err := row.Scan(&visible)
+	return visible, err
+}
+
+const createFollow = `-- name: CreateFollow :one
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING follower_id, followee_id, created_at
+`
+
+type CreateFollowParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+func (q *Queries) CreateFollow(ctx context.Context, arg CreateFollowParams) (Follow, error) {
+	row := q.db.QueryRowContext(ctx, createFollow, arg.FollowerID, arg.FolloweeID)
+	var i Follow
+	err := row.Scan(&i.FollowerID, &i.FolloweeID, &i.CreatedAt)
+	return i, err
+}
+
+const createFollowRequest = `-- name: CreateFollowRequest :one
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING requester_id, target_id, created_at
+`
+
+type CreateFollowRequestParams struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+}
+
+func (q *Queries) CreateFollowRequest(ctx context.Context, arg CreateFollowRequestParams) (FollowRequest, error) {
+	row := q.db.QueryRowContext(ctx, createFollowRequest, arg.RequesterID, arg.TargetID)
+	var i FollowRequest
+	err := row.Scan(&i.RequesterID, &i.TargetID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteFollow = `-- name: DeleteFollow :one
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2
+RETURNING follower_id, followee_id, created_at
+`
+
+type DeleteFollowParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+func (q *Queries) DeleteFollow(ctx context.Context, arg DeleteFollowParams) (Follow, error) {
+	row := q.db.QueryRowContext(ctx, deleteFollow, arg.FollowerID, arg.FolloweeID)
+	var i Follow
+	err := row.Scan(&i.FollowerID, &i.FolloweeID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteFollowRequest = `-- name: DeleteFollowRequest :one
+DELETE FROM follow_requests
+WHERE requester_id = $1
+AND target_id = $2
+RETURNING requester_id, target_id, created_at
+`
+
+type DeleteFollowRequestParams struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+}
+
+func (q *Queries) DeleteFollowRequest(ctx context.Context, arg DeleteFollowRequestParams) (FollowRequest, error) {
+	row := q.db.QueryRowContext(ctx, deleteFollowRequest, arg.RequesterID, arg.TargetID)
+	var i FollowRequest
+	err := row.Scan(&i.RequesterID, &i.TargetID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteFollowRequestsBetween = `-- name: DeleteFollowRequestsBetween :exec
+DELETE FROM follow_requests
+WHERE (requester_id = $1 AND target_id = $2)
+OR (requester_id = $2 AND target_id = $1)
+`
+
+type DeleteFollowRequestsBetweenParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+func (q *Queries) DeleteFollowRequestsBetween(ctx context.Context, arg DeleteFollowRequestsBetweenParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFollowRequestsBetween, arg.UserID, arg.OtherID)
+	return err
+}
+
+const deleteFollowsBetween = `-- name: DeleteFollowsBetween :many
+DELETE FROM follows
+WHERE (follower_id = $1 AND followee_id = $2)
+OR (follower_id = $2 AND followee_id = $1)
+RETURNING follower_id, followee_id, created_at
+`
+
+type DeleteFollowsBetweenParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+func (q *Queries) DeleteFollowsBetween(ctx context.Context, arg DeleteFollowsBetweenParams) ([]Follow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteFollowsBetween, arg.UserID, arg.OtherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Follow
+	for rows.Next() {
+		var i Follow
+		if err := rows.Scan(&i.FollowerID, &i.FolloweeID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollow = `-- name: GetFollow :one
+SELECT follower_id, followee_id, created_at FROM follows
+WHERE follower_id = $1
+AND followee_id = $2
+`
+
+type GetFollowParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+func (q *Queries) GetFollow(ctx context.Context, arg GetFollowParams) (Follow, error) {
+	row := q.db.QueryRowContext(ctx, getFollow, arg.FollowerID, arg.FolloweeID)
+	var i Follow
+	err := row.Scan(&i.FollowerID, &i.FolloweeID, &i.CreatedAt)
+	return i, err
+}
+
+const listFollowRequestsAsc = `-- name: ListFollowRequestsAsc :many
+SELECT users.id, users.username, follow_requests.created_at FROM follow_requests
+JOIN users ON users.id = follow_requests.requester_id
+WHERE follow_requests.target_id = $1
+AND ($2::timestamp IS NULL
+   OR (follow_requests.created_at, follow_requests.requester_id) > ($2::timestamp, $3::uuid))
+ORDER BY follow_requests.created_at ASC, follow_requests.requester_id ASC
+LIMIT $4
+`
+
+type ListFollowRequestsAscParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListFollowRequestsAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListFollowRequestsAsc(ctx context.Context, arg ListFollowRequestsAscParams) ([]ListFollowRequestsAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowRequestsAsc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowRequestsAscRow
+	for rows.Next() {
+		var i ListFollowRequestsAscRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowRequestsDesc = `-- name: ListFollowRequestsDesc :many
+SELECT users.id, users.username, follow_requests.created_at FROM follow_requests
+JOIN users ON users.id = follow_requests.requester_id
+WHERE follow_requests.target_id = $1
+AND ($2::timestamp IS NULL
+   OR (follow_requests.created_at, follow_requests.requester_id) < ($2::timestamp, $3::uuid))
+ORDER BY follow_requests.created_at DESC, follow_requests.requester_id DESC
+LIMIT $4
+`
+
+type ListFollowRequestsDescParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListFollowRequestsDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListFollowRequestsDesc(ctx context.Context, arg ListFollowRequestsDescParams) ([]ListFollowRequestsDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowRequestsDesc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowRequestsDescRow
+	for rows.Next() {
+		var i ListFollowRequestsDescRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowersAsc = `-- name: ListFollowersAsc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = $1
+AND NOT users_blocked(users.id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (follows.created_at, follows.follower_id) > ($3::timestamp, $4::uuid))
+ORDER BY follows.created_at ASC, follows.follower_id ASC
+LIMIT $5
+`
+
+type ListFollowersAscParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListFollowersAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListFollowersAsc(ctx context.Context, arg ListFollowersAscParams) ([]ListFollowersAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowersAsc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowersAscRow
+	for rows.Next() {
+		var i ListFollowersAscRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowersDesc = `-- name: ListFollowersDesc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = $1
+AND NOT users_blocked(users.id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (follows.created_at, follows.follower_id) < ($3::timestamp, $4::uuid))
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT $5
+`
+
+type ListFollowersDescParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListFollowersDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListFollowersDesc(ctx context.Context, arg ListFollowersDescParams) ([]ListFollowersDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowersDesc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowersDescRow
+	for rows.Next() {
+		var i ListFollowersDescRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingAsc = `-- name: ListFollowingAsc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = $1
+AND NOT users_blocked(users.id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (follows.created_at, follows.followee_id) > ($3::timestamp, $4::uuid))
+ORDER BY follows.created_at ASC, follows.followee_id ASC
+LIMIT $5
+`
+
+type ListFollowingAscParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListFollowingAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListFollowingAsc(ctx context.Context, arg ListFollowingAscParams) ([]ListFollowingAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowingAsc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowingAscRow
+	for rows.Next() {
+		var i ListFollowingAscRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingDesc = `-- name: ListFollowingDesc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = $1
+AND NOT users_blocked(users.id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (follows.created_at, follows.followee_id) < ($3::timestamp, $4::uuid))
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT $5
+`
+
+type ListFollowingDescParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListFollowingDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListFollowingDesc(ctx context.Context, arg ListFollowingDescParams) ([]ListFollowingDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowingDesc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowingDescRow
+	for rows.Next() {
+		var i ListFollowingDescRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFollowCounts = `-- name: UpdateFollowCounts :exec
+UPDATE users SET
+follower_count = follower_count + CASE WHEN id = $1::uuid THEN $2::int ELSE 0 END,
+following_count = following_count + CASE WHEN id = $3::uuid THEN $2::int ELSE 0 END
+WHERE id IN ($3::uuid, $1::uuid)
+`
+
+type UpdateFollowCountsParams struct {
+	FolloweeID uuid.UUID
+	Delta      int32
+	FollowerID uuid.UUID
+}
+
+func (q *Queries) UpdateFollowCounts(ctx context.Context, arg UpdateFollowCountsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFollowCounts, arg.FolloweeID, arg.Delta, arg.FollowerID)
+	return err
+}
@@ -0,0 +1,105 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: login_throttles.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const getLoginThrottles = `-- name: GetLoginThrottles :many
+SELECT key, failures, last_failure_at, locked_until FROM login_throttles
+WHERE key = ANY($1::TEXT[])
+`
+
+func (q *Queries) GetLoginThrottles(ctx context.Context, keys []string) ([]LoginThrottle, error) {
+	rows, err := q.db.QueryContext(ctx, getLoginThrottles, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoginThrottle
+	for rows.Next() {
+		var i LoginThrottle
+		if err := rows.Scan(
+			&i.Key,
+			&i.Failures,
+			&i.LastFailureAt,
+			&i.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_throttles SET locked_until = $1
+WHERE key = $2
+`
+
+type LockLoginParams struct {
+	LockedUntil sql.NullTime
+	Key         string
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.ExecContext(ctx, lockLogin, arg.LockedUntil, arg.Key)
+	return err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (
+   $1,
+   1,
+   NOW()
+)
+ON CONFLICT (key) DO UPDATE SET
+failures = CASE
+   WHEN login_throttles.last_failure_at < $2 THEN 1
+   ELSE login_throttles.failures + 1
+END,
+last_failure_at = NOW()
+RETURNING key, failures, last_failure_at, locked_until
+`
+
+type RecordLoginFailureParams struct {
+	Key         string
+	ResetBefore time.Time
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Key, arg.ResetBefore)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailureAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const resetLoginThrottle = `-- name: ResetLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1
+`
+
+func (q *Queries) ResetLoginThrottle(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, resetLoginThrottle, key)
+	return err
+}
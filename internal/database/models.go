@@ -6,21 +6,157 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Post struct {
+type ApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+	CreatedAt   time.Time
+}
+
+type LoginThrottle struct {
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type MfaRecoveryCode struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
 	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	CreatedAt     time.Time
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        sql.NullTime
+	SessionID     uuid.NullUUID
+}
+
+type OauthClient struct {
+	ID           string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	Name         string
+	RedirectUris []string
+	SecretHash   sql.NullString
+}
+
+type OidcLoginState struct {
+	StateHash    string
+	CreatedAt    time.Time
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       uuid.NullUUID
+	ExpiresAt    time.Time
+	BindingHash  string
+}
+
+type PasswordResetToken struct {
+	TokenHash string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Post struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	Body           string
+	Likes          int32
+	EditedAt       sql.NullTime
+	ParentID       uuid.NullUUID
+	RootID         uuid.NullUUID
+	ReplyCount     int32
+	DeletedAt      sql.NullTime
+	RepostOfID     uuid.NullUUID
+	QuoteOfID      uuid.NullUUID
+	RepostCount    int32
+	Visibility     string
+	ReactionCounts json.RawMessage
+	FannedOut      bool
+}
+
+type PostMention struct {
+	PostID uuid.UUID
+	UserID uuid.UUID
+}
+
+type PostReaction struct {
+	PostID    uuid.UUID
+	UserID    uuid.UUID
+	Reaction  string
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type PostRevision struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+	EditedBy  uuid.NullUUID
 	Body      string
-	Likes     int32
 }
 
-type PostsLike struct {
+type PostsLikesArchive struct {
 	ID        uuid.UUID
 	PostID    uuid.UUID
 	UserID    uuid.UUID
@@ -28,12 +164,18 @@ type PostsLike struct {
 }
 
 type RefreshToken struct {
-	Token     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	Token           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uuid.UUID
+	ExpiresAt       time.Time
+	RevokedAt       sql.NullTime
+	FamilyID        uuid.UUID
+	ReplacedBy      sql.NullString
+	ReuseDetectedAt sql.NullTime
+	ClientID        sql.NullString
+	Scopes          []string
+	RevokedReason   sql.NullString
 }
 
 type Report struct {
@@ -45,12 +187,51 @@ type Report struct {
 	Reason    string
 }
 
+type Session struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uuid.UUID
+	UserAgent       string
+	IpAddress       string
+	DeviceName      sql.NullString
+	LastUsedAt      time.Time
+	RevokedAt       sql.NullTime
+	ReuseDetectedAt sql.NullTime
+	ClientID        sql.NullString
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
 type User struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Email            string
+	Username         string
+	Password         string
+	IsPremium        bool
+	Role             string
+	EmailVerifiedAt  sql.NullTime
+	PendingEmail     sql.NullString
+	TotpSecret       sql.NullString
+	TotpEnabledAt    sql.NullTime
+	TotpLastUsedStep sql.NullInt64
+	FollowerCount    int32
+	FollowingCount   int32
+	IsPrivate        bool
+}
+
+type UserIdentity struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Provider  string
+	Subject   string
 	Email     string
-	Username  string
-	Password  string
-	IsPremium bool
 }
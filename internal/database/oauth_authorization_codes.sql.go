@@ -0,0 +1,139 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: oauth_authorization_codes.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const consumeAuthorizationCode = `-- name: ConsumeAuthorizationCode :one
+UPDATE oauth_authorization_codes SET used_at = NOW()
+WHERE code_hash = $1
+AND used_at IS NULL
+AND expires_at > NOW()
+RETURNING code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, session_id
+`
+
+func (q *Queries) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error) {
+	row := q.db.QueryRowContext(ctx, consumeAuthorizationCode, codeHash)
+	var i OauthAuthorizationCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.CreatedAt,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.SessionID,
+	)
+	return i, err
+}
+
+const createAuthorizationCode = `-- name: CreateAuthorizationCode :one
+INSERT INTO oauth_authorization_codes (code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5,
+   $6,
+   $7
+)
+RETURNING code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, session_id
+`
+
+type CreateAuthorizationCodeParams struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+func (q *Queries) CreateAuthorizationCode(ctx context.Context, arg CreateAuthorizationCodeParams) (OauthAuthorizationCode, error) {
+	row := q.db.QueryRowContext(ctx, createAuthorizationCode,
+		arg.CodeHash,
+		arg.ClientID,
+		arg.UserID,
+		arg.RedirectUri,
+		pq.Array(arg.Scopes),
+		arg.CodeChallenge,
+		arg.ExpiresAt,
+	)
+	var i OauthAuthorizationCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.CreatedAt,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.SessionID,
+	)
+	return i, err
+}
+
+const deleteExpiredAuthorizationCodes = `-- name: DeleteExpiredAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes
+WHERE expires_at <= NOW() - INTERVAL '1 day'
+`
+
+func (q *Queries) DeleteExpiredAuthorizationCodes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredAuthorizationCodes)
+	return err
+}
+
+const getAuthorizationCode = `-- name: GetAuthorizationCode :one
+SELECT code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, session_id FROM oauth_authorization_codes
+WHERE code_hash = $1
+`
+
+func (q *Queries) GetAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error) {
+	row := q.db.QueryRowContext(ctx, getAuthorizationCode, codeHash)
+	var i OauthAuthorizationCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.CreatedAt,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.SessionID,
+	)
+	return i, err
+}
+
+const setAuthorizationCodeSession = `-- name: SetAuthorizationCodeSession :exec
+UPDATE oauth_authorization_codes SET session_id = $2
+WHERE code_hash = $1
+`
+
+type SetAuthorizationCodeSessionParams struct {
+	CodeHash  string
+	SessionID uuid.NullUUID
+}
+
+func (q *Queries) SetAuthorizationCodeSession(ctx context.Context, arg SetAuthorizationCodeSessionParams) error {
+	_, err := q.db.ExecContext(ctx, setAuthorizationCodeSession, arg.CodeHash, arg.SessionID)
+	return err
+}
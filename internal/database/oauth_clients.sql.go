@@ -0,0 +1,141 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: oauth_clients.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createOAuthClient = `-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, created_at, updated_at, user_id, name, redirect_uris, secret_hash)
+VALUES (
+   $1,
+   NOW(),
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5
+)
+RETURNING id, created_at, updated_at, user_id, name, redirect_uris, secret_hash
+`
+
+type CreateOAuthClientParams struct {
+	ID           string
+	UserID       uuid.UUID
+	Name         string
+	RedirectUris []string
+	SecretHash   sql.NullString
+}
+
+func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, createOAuthClient,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		pq.Array(arg.RedirectUris),
+		arg.SecretHash,
+	)
+	var i OauthClient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.RedirectUris),
+		&i.SecretHash,
+	)
+	return i, err
+}
+
+const deleteOAuthClient = `-- name: DeleteOAuthClient :one
+DELETE FROM oauth_clients
+WHERE id = $1
+AND user_id = $2
+RETURNING id, created_at, updated_at, user_id, name, redirect_uris, secret_hash
+`
+
+type DeleteOAuthClientParams struct {
+	ID     string
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteOAuthClient(ctx context.Context, arg DeleteOAuthClientParams) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, deleteOAuthClient, arg.ID, arg.UserID)
+	var i OauthClient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.RedirectUris),
+		&i.SecretHash,
+	)
+	return i, err
+}
+
+const getOAuthClient = `-- name: GetOAuthClient :one
+SELECT id, created_at, updated_at, user_id, name, redirect_uris, secret_hash FROM oauth_clients
+WHERE id = $1
+`
+
+func (q *Queries) GetOAuthClient(ctx context.Context, id string) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, getOAuthClient, id)
+	var i OauthClient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.RedirectUris),
+		&i.SecretHash,
+	)
+	return i, err
+}
+
+const listOAuthClientsByUser = `-- name: ListOAuthClientsByUser :many
+SELECT id, created_at, updated_at, user_id, name, redirect_uris, secret_hash FROM oauth_clients
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListOAuthClientsByUser(ctx context.Context, userID uuid.UUID) ([]OauthClient, error) {
+	rows, err := q.db.QueryContext(ctx, listOAuthClientsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OauthClient
+	for rows.Next() {
+		var i OauthClient
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Name,
+			pq.Array(&i.RedirectUris),
+			&i.SecretHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
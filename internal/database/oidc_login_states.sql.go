@@ -0,0 +1,95 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: oidc_login_states.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+AND expires_at > NOW()
+RETURNING state_hash, created_at, provider, nonce, code_verifier, user_id, expires_at, binding_hash
+`
+
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error) {
+	row := q.db.QueryRowContext(ctx, consumeOIDCLoginState, stateHash)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.CreatedAt,
+		&i.Provider,
+		&i.Nonce,
+		&i.CodeVerifier,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.BindingHash,
+	)
+	return i, err
+}
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :one
+INSERT INTO oidc_login_states (state_hash, created_at, provider, nonce, code_verifier, user_id, expires_at, binding_hash)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5,
+   $6,
+   $7
+)
+RETURNING state_hash, created_at, provider, nonce, code_verifier, user_id, expires_at, binding_hash
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       uuid.NullUUID
+	ExpiresAt    time.Time
+	BindingHash  string
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) (OidcLoginState, error) {
+	row := q.db.QueryRowContext(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.Provider,
+		arg.Nonce,
+		arg.CodeVerifier,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.BindingHash,
+	)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.CreatedAt,
+		&i.Provider,
+		&i.Nonce,
+		&i.CodeVerifier,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.BindingHash,
+	)
+	return i, err
+}
+
+const deleteExpiredOIDCLoginStates = `-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredOIDCLoginStates)
+	return err
+}
@@ -0,0 +1,247 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: post_reactions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const addPostReactionCount = `-- name: AddPostReactionCount :exec
+UPDATE posts SET likes = likes + CASE WHEN $1::text = 'like' THEN $2::int ELSE 0 END,
+reaction_counts = CASE
+   WHEN COALESCE((reaction_counts->>$1::text)::int, 0) + $2::int <= 0
+      THEN reaction_counts - $1::text
+   ELSE jsonb_set(
+      reaction_counts,
+      ARRAY[$1::text],
+      to_jsonb(COALESCE((reaction_counts->>$1::text)::int, 0) + $2::int)
+   )
+END
+WHERE id = $3
+`
+
+type AddPostReactionCountParams struct {
+	Reaction string
+	Delta    int32
+	PostID   uuid.UUID
+}
+
+// likes - the count of the like reaction, kept as its own column for sorting
+func (q *Queries) AddPostReactionCount(ctx context.Context, arg AddPostReactionCountParams) error {
+	_, err := q.db.ExecContext(ctx, addPostReactionCount, arg.Reaction, arg.Delta, arg.PostID)
+	return err
+}
+
+const deleteReaction = `-- name: DeleteReaction :one
+DELETE FROM post_reactions
+WHERE post_id = $1 AND user_id = $2
+AND ($3::text IS NULL OR reaction = $3::text)
+RETURNING post_id, user_id, reaction, created_at, id
+`
+
+type DeleteReactionParams struct {
+	PostID   uuid.UUID
+	UserID   uuid.UUID
+	Reaction sql.NullString
+}
+
+func (q *Queries) DeleteReaction(ctx context.Context, arg DeleteReactionParams) (PostReaction, error) {
+	row := q.db.QueryRowContext(ctx, deleteReaction, arg.PostID, arg.UserID, arg.Reaction)
+	var i PostReaction
+	err := row.Scan(
+		&i.PostID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedAt,
+		&i.ID,
+	)
+	return i, err
+}
+
+const getReaction = `-- name: GetReaction :one
+SELECT post_id, user_id, reaction, created_at, id FROM post_reactions
+WHERE post_id = $1 AND user_id = $2
+`
+
+type GetReactionParams struct {
+	PostID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetReaction(ctx context.Context, arg GetReactionParams) (PostReaction, error) {
+	row := q.db.QueryRowContext(ctx, getReaction, arg.PostID, arg.UserID)
+	var i PostReaction
+	err := row.Scan(
+		&i.PostID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedAt,
+		&i.ID,
+	)
+	return i, err
+}
+
+const listReactionsAsc = `-- name: ListReactionsAsc :many
+SELECT users.id, users.username, post_reactions.reaction, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = $1
+AND author_visible(post_reactions.user_id, $2::uuid)
+AND ($3::text IS NULL OR post_reactions.reaction = $3::text)
+AND ($4::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) > ($4::timestamp, $5::uuid))
+ORDER BY post_reactions.created_at ASC, post_reactions.user_id ASC
+LIMIT $6
+`
+
+type ListReactionsAscParams struct {
+	PostID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	Reaction        sql.NullString
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListReactionsAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	Reaction  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListReactionsAsc(ctx context.Context, arg ListReactionsAscParams) ([]ListReactionsAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReactionsAsc,
+		arg.PostID,
+		arg.ViewerID,
+		arg.Reaction,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReactionsAscRow
+	for rows.Next() {
+		var i ListReactionsAscRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Reaction,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReactionsDesc = `-- name: ListReactionsDesc :many
+SELECT users.id, users.username, post_reactions.reaction, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = $1
+AND author_visible(post_reactions.user_id, $2::uuid)
+AND ($3::text IS NULL OR post_reactions.reaction = $3::text)
+AND ($4::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) < ($4::timestamp, $5::uuid))
+ORDER BY post_reactions.created_at DESC, post_reactions.user_id DESC
+LIMIT $6
+`
+
+type ListReactionsDescParams struct {
+	PostID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	Reaction        sql.NullString
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListReactionsDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	Reaction  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListReactionsDesc(ctx context.Context, arg ListReactionsDescParams) ([]ListReactionsDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReactionsDesc,
+		arg.PostID,
+		arg.ViewerID,
+		arg.Reaction,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReactionsDescRow
+	for rows.Next() {
+		var i ListReactionsDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Reaction,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setReaction = `-- name: SetReaction :one
+INSERT INTO post_reactions (post_id, user_id, reaction, created_at)
+VALUES (
+   $1,
+   $2,
+   $3,
+   NOW()
+)
+ON CONFLICT (post_id, user_id) DO UPDATE SET
+reaction = EXCLUDED.reaction, created_at = EXCLUDED.created_at
+RETURNING post_id, user_id, reaction, created_at, id
+`
+
+type SetReactionParams struct {
+	PostID   uuid.UUID
+	UserID   uuid.UUID
+	Reaction string
+}
+
+func (q *Queries) SetReaction(ctx context.Context, arg SetReactionParams) (PostReaction, error) {
+	row := q.db.QueryRowContext(ctx, setReaction, arg.PostID, arg.UserID, arg.Reaction)
+	var i PostReaction
+	err := row.Scan(
+		&i.PostID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedAt,
+		&i.ID,
+	)
+	return i, err
+}
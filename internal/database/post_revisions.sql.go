@@ -0,0 +1,94 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: post_revisions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createPostRevision = `-- name: CreatePostRevision :one
+INSERT INTO post_revisions (id, created_at, post_id, edited_by, body)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3,
+   $4
+)
+RETURNING id, created_at, post_id, edited_by, body
+`
+
+type CreatePostRevisionParams struct {
+	ID       uuid.UUID
+	PostID   uuid.UUID
+	EditedBy uuid.NullUUID
+	Body     string
+}
+
+func (q *Queries) CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) (PostRevision, error) {
+	row := q.db.QueryRowContext(ctx, createPostRevision,
+		arg.ID,
+		arg.PostID,
+		arg.EditedBy,
+		arg.Body,
+	)
+	var i PostRevision
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PostID,
+		&i.EditedBy,
+		&i.Body,
+	)
+	return i, err
+}
+
+const deletePostRevisions = `-- name: DeletePostRevisions :exec
+DELETE FROM post_revisions
+WHERE post_id = $1
+`
+
+func (q *Queries) DeletePostRevisions(ctx context.Context, postID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePostRevisions, postID)
+	return err
+}
+
+const listPostRevisions = `-- name: ListPostRevisions :many
+SELECT id, created_at, post_id, edited_by, body FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListPostRevisions(ctx context.Context, postID uuid.UUID) ([]PostRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listPostRevisions, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PostID,
+			&i.EditedBy,
+			&i.Body,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,14 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const changePostByID = `-- name: ChangePostByID :exec
+const changePostByID = `-- name: ChangePostByID :one
 UPDATE posts SET
-body = $1, updated_at = NOW()
+body = $1, updated_at = NOW(), edited_at = NOW()
 WHERE id = $2
+RETURNING id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out
 `
 
 type ChangePostByIDParams struct {
@@ -22,29 +25,59 @@ type ChangePostByIDParams struct {
 	ID   uuid.UUID
 }
 
-func (q *Queries) ChangePostByID(ctx context.Context, arg ChangePostByIDParams) error {
-	_, err := q.db.ExecContext(ctx, changePostByID, arg.Body, arg.ID)
-	return err
+func (q *Queries) ChangePostByID(ctx context.Context, arg ChangePostByIDParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, changePostByID, arg.Body, arg.ID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.Likes,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.RepostCount,
+		&i.Visibility,
+		&i.ReactionCounts,
+		&i.FannedOut,
+	)
+	return i, err
 }
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, user_id, body, likes)
+INSERT INTO posts (id, created_at, updated_at, user_id, body, likes, parent_id, root_id, repost_of_id, quote_of_id, visibility)
 VALUES (
    $1,
    NOW(),
    NOW(),
    $2,
    $3,
-   $4
+   $4,
+   $5,
+   $6,
+   $7,
+   $8,
+   $9
 )
-RETURNING id, created_at, updated_at, user_id, body, likes
+RETURNING id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out
 `
 
 type CreatePostParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
-	Body   string
-	Likes  int32
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Body       string
+	Likes      int32
+	ParentID   uuid.NullUUID
+	RootID     uuid.NullUUID
+	RepostOfID uuid.NullUUID
+	QuoteOfID  uuid.NullUUID
+	Visibility string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -53,6 +86,11 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.UserID,
 		arg.Body,
 		arg.Likes,
+		arg.ParentID,
+		arg.RootID,
+		arg.RepostOfID,
+		arg.QuoteOfID,
+		arg.Visibility,
 	)
 	var i Post
 	err := row.Scan(
@@ -62,10 +100,59 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.UserID,
 		&i.Body,
 		&i.Likes,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.RepostCount,
+		&i.Visibility,
+		&i.ReactionCounts,
+		&i.FannedOut,
 	)
 	return i, err
 }
 
+const createPostMentions = `-- name: CreatePostMentions :exec
+INSERT INTO post_mentions (post_id, user_id)
+SELECT $1::uuid, users.id FROM users
+WHERE users.username = ANY($2::text[])
+AND NOT users_blocked(users.id, (SELECT posts.user_id FROM posts WHERE posts.id = $1::uuid))
+ON CONFLICT DO NOTHING
+`
+
+type CreatePostMentionsParams struct {
+	PostID    uuid.UUID
+	Usernames []string
+}
+
+func (q *Queries) CreatePostMentions(ctx context.Context, arg CreatePostMentionsParams) error {
+	_, err := q.db.ExecContext(ctx, createPostMentions, arg.PostID, pq.Array(arg.Usernames))
+	return err
+}
+
+const decrementPostReplyCount = `-- name: DecrementPostReplyCount :exec
+UPDATE posts SET reply_count = reply_count - 1
+WHERE id = $1
+`
+
+func (q *Queries) DecrementPostReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementPostReplyCount, id)
+	return err
+}
+
+const decrementPostRepostCount = `-- name: DecrementPostRepostCount :exec
+UPDATE posts SET repost_count = repost_count - 1
+WHERE id = $1
+`
+
+func (q *Queries) DecrementPostRepostCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementPostRepostCount, id)
+	return err
+}
+
 const deletePostByID = `-- name: DeletePostByID :exec
 DELETE FROM posts WHERE id = $1
 `
@@ -75,13 +162,37 @@ func (q *Queries) DeletePostByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deletePostMentions = `-- name: DeletePostMentions :exec
+DELETE FROM post_mentions
+WHERE post_id = $1
+`
+
+func (q *Queries) DeletePostMentions(ctx context.Context, postID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePostMentions, postID)
+	return err
+}
+
+const deleteRepostsOf = `-- name: DeleteRepostsOf :exec
+DELETE FROM posts
+WHERE repost_of_id = $1
+`
+
+func (q *Queries) DeleteRepostsOf(ctx context.Context, repostOfID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRepostsOf, repostOfID)
+	return err
+}
+
 const getMostLikedPosts = `-- name: GetMostLikedPosts :many
-SELECT id, created_at, updated_at, user_id, body, likes FROM posts
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE deleted_at IS NULL
+AND visibility = 'public'
+AND post_visible(posts.id, $1::uuid)
+AND post_visible(posts.repost_of_id, $1::uuid)
 ORDER BY likes ASC LIMIT 10
 `
 
-func (q *Queries) GetMostLikedPosts(ctx context.Context) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getMostLikedPosts)
+func (q *Queries) GetMostLikedPosts(ctx context.Context, viewerID uuid.NullUUID) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getMostLikedPosts, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +207,17 @@ func (q *Queries) GetMostLikedPosts(ctx context.Context) ([]Post, error) {
 			&i.UserID,
 			&i.Body,
 			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
 		); err != nil {
 			return nil, err
 		}
@@ -111,7 +233,7 @@ func (q *Queries) GetMostLikedPosts(ctx context.Context) ([]Post, error) {
 }
 
 const getPostByID = `-- name: GetPostByID :one
-SELECT id, created_at, updated_at, user_id, body, likes FROM posts
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
 WHERE id = $1
 `
 
@@ -125,16 +247,462 @@ func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
 		&i.UserID,
 		&i.Body,
 		&i.Likes,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.RepostCount,
+		&i.Visibility,
+		&i.ReactionCounts,
+		&i.FannedOut,
+	)
+	return i, err
+}
+
+const getPostByIDForUpdate = `-- name: GetPostByIDForUpdate :one
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetPostByIDForUpdate(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByIDForUpdate, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.Likes,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.RepostCount,
+		&i.Visibility,
+		&i.ReactionCounts,
+		&i.FannedOut,
+	)
+	return i, err
+}
+
+const getPostsByIDs = `-- name: GetPostsByIDs :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE id = ANY($1::uuid[])
+AND post_visible(posts.id, $2::uuid)
+`
+
+type GetPostsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetPostsByIDs(ctx context.Context, arg GetPostsByIDsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepost = `-- name: GetRepost :one
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE user_id = $1
+AND repost_of_id = $2
+`
+
+type GetRepostParams struct {
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+}
+
+func (q *Queries) GetRepost(ctx context.Context, arg GetRepostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getRepost, arg.UserID, arg.RepostOfID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.Likes,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.RepostCount,
+		&i.Visibility,
+		&i.ReactionCounts,
+		&i.FannedOut,
 	)
 	return i, err
 }
 
-const getPosts = `-- name: GetPosts :many
-SELECT id, created_at, updated_at, user_id, body, likes FROM posts
+const incrementPostReplyCount = `-- name: IncrementPostReplyCount :exec
+UPDATE posts SET reply_count = reply_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementPostReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementPostReplyCount, id)
+	return err
+}
+
+const incrementPostRepostCount = `-- name: IncrementPostRepostCount :exec
+UPDATE posts SET repost_count = repost_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementPostRepostCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementPostRepostCount, id)
+	return err
+}
+
+const listPostsAsc = `-- name: ListPostsAsc :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE ($1::timestamp IS NULL
+   OR (created_at, id) > ($1::timestamp, $2::uuid))
+AND deleted_at IS NULL
+AND ($3::uuid IS NULL OR user_id = $3::uuid)
+AND post_visible(posts.id, $4::uuid)
+AND post_visible(posts.repost_of_id, $4::uuid)
+AND ($3::uuid IS NOT NULL OR visibility <> 'unlisted')
+AND ($3::uuid IS NOT NULL OR NOT EXISTS (
+   SELECT 1 FROM mutes WHERE muter_id = $4::uuid AND muted_id = posts.user_id
+))
+AND ($5::timestamp IS NULL OR created_at >= $5::timestamp)
+AND ($6::timestamp IS NULL OR created_at < $6::timestamp)
+ORDER BY created_at ASC, id ASC
+LIMIT $7
+`
+
+type ListPostsAscParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	Limit           int32
+}
+
+// Unlisted posts and muted accounts are only shown when the author's posts are asked for
+func (q *Queries) ListPostsAsc(ctx context.Context, arg ListPostsAscParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsAsc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsDesc = `-- name: ListPostsDesc :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE ($1::timestamp IS NULL
+   OR (created_at, id) < ($1::timestamp, $2::uuid))
+AND deleted_at IS NULL
+AND ($3::uuid IS NULL OR user_id = $3::uuid)
+AND post_visible(posts.id, $4::uuid)
+AND post_visible(posts.repost_of_id, $4::uuid)
+AND ($3::uuid IS NOT NULL OR visibility <> 'unlisted')
+AND ($3::uuid IS NOT NULL OR NOT EXISTS (
+   SELECT 1 FROM mutes WHERE muter_id = $4::uuid AND muted_id = posts.user_id
+))
+AND ($5::timestamp IS NULL OR created_at >= $5::timestamp)
+AND ($6::timestamp IS NULL OR created_at < $6::timestamp)
+ORDER BY created_at DESC, id DESC
+LIMIT $7
+`
+
+type ListPostsDescParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	Limit           int32
+}
+
+// Unlisted posts and muted accounts are only shown when the author's posts are asked for
+func (q *Queries) ListPostsDesc(ctx context.Context, arg ListPostsDescParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsDesc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRepliesAsc = `-- name: ListRepliesAsc :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE ($1::timestamp IS NULL
+   OR (created_at, id) > ($1::timestamp, $2::uuid))
+AND parent_id = $3
+AND post_visible(posts.id, $4::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListRepliesAscParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ParentID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListRepliesAsc(ctx context.Context, arg ListRepliesAscParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listRepliesAsc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ParentID,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRepliesByParents = `-- name: ListRepliesByParents :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE parent_id = ANY($1::uuid[])
+AND post_visible(posts.id, $2::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $3
+`
+
+type ListRepliesByParentsParams struct {
+	ParentIds []uuid.UUID
+	ViewerID  uuid.NullUUID
+	Limit     int32
+}
+
+func (q *Queries) ListRepliesByParents(ctx context.Context, arg ListRepliesByParentsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listRepliesByParents, pq.Array(arg.ParentIds), arg.ViewerID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRepliesDesc = `-- name: ListRepliesDesc :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE ($1::timestamp IS NULL
+   OR (created_at, id) < ($1::timestamp, $2::uuid))
+AND parent_id = $3
+AND post_visible(posts.id, $4::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
-func (q *Queries) GetPosts(ctx context.Context) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPosts)
+type ListRepliesDescParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ParentID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListRepliesDesc(ctx context.Context, arg ListRepliesDescParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listRepliesDesc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ParentID,
+		arg.ViewerID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +717,17 @@ func (q *Queries) GetPosts(ctx context.Context) ([]Post, error) {
 			&i.UserID,
 			&i.Body,
 			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
 		); err != nil {
 			return nil, err
 		}
@@ -162,3 +741,67 @@ func (q *Queries) GetPosts(ctx context.Context) ([]Post, error) {
 	}
 	return items, nil
 }
+
+const postVisible = `-- name: PostVisible :one
+SELECT post_visible($1::uuid, $2::uuid)::boolean AS visible
+`
+
+type PostVisibleParams struct {
+	PostID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) PostVisible(ctx context.Context, arg PostVisibleParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, postVisible, arg.PostID, arg.ViewerID)
+	var visible bool
+	err := row.Scan(&visible)
+	return visible, err
+}
+
+const setPostVisibility = `-- name: SetPostVisibility :one
+UPDATE posts SET
+visibility = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out
+`
+
+type SetPostVisibilityParams struct {
+	Visibility string
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetPostVisibility(ctx context.Context, arg SetPostVisibilityParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, setPostVisibility, arg.Visibility, arg.ID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.Likes,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.RepostCount,
+		&i.Visibility,
+		&i.ReactionCounts,
+		&i.FannedOut,
+	)
+	return i, err
+}
+
+const tombstonePost = `-- name: TombstonePost :exec
+UPDATE posts SET
+body = '', quote_of_id = NULL, repost_count = 0, updated_at = NOW(), deleted_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstonePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstonePost, id)
+	return err
+}
@@ -7,109 +7,317 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const checkIfUserLikeAlready = `-- name: CheckIfUserLikeAlready :exec
-SELECT id FROM posts_likes
-WHERE user_id = $1
+const getPostLikes = `-- name: GetPostLikes :one
+SELECT likes from posts
+WHERE id = $1
 `
 
-func (q *Queries) CheckIfUserLikeAlready(ctx context.Context, userID uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, checkIfUserLikeAlready, userID)
-	return err
+func (q *Queries) GetPostLikes(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getPostLikes, id)
+	var likes int32
+	err := row.Scan(&likes)
+	return likes, err
 }
 
-const decrementPostLike = `-- name: DecrementPostLike :exec
-UPDATE posts SET likes = likes - 1
-WHERE id = $1
+const listLikedPostIDs = `-- name: ListLikedPostIDs :many
+SELECT post_id FROM post_reactions
+WHERE user_id = $1
+AND reaction = 'like'
+AND post_id = ANY($2::uuid[])
 `
 
-func (q *Queries) DecrementPostLike(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, decrementPostLike, id)
-	return err
+type ListLikedPostIDsParams struct {
+	UserID  uuid.UUID
+	PostIds []uuid.UUID
+}
+
+func (q *Queries) ListLikedPostIDs(ctx context.Context, arg ListLikedPostIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listLikedPostIDs, arg.UserID, pq.Array(arg.PostIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var post_id uuid.UUID
+		if err := rows.Scan(&post_id); err != nil {
+			return nil, err
+		}
+		items = append(items, post_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const dislikePost = `-- name: DislikePost :exec
-DELETE FROM posts_likes 
-WHERE user_id = $1 AND post_id = $2
+const listLikedPostsAsc = `-- name: ListLikedPostsAsc :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.user_id, posts.body, posts.likes, posts.edited_at, posts.parent_id, posts.root_id, posts.reply_count, posts.deleted_at, posts.repost_of_id, posts.quote_of_id, posts.repost_count, posts.visibility, posts.reaction_counts, posts.fanned_out, post_reactions.created_at AS liked_at FROM post_reactions
+JOIN posts ON posts.id = post_reactions.post_id
+WHERE post_reactions.user_id = $1
+AND post_reactions.reaction = 'like'
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, $2::uuid)
+AND post_visible(posts.repost_of_id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.post_id) > ($3::timestamp, $4::uuid))
+ORDER BY post_reactions.created_at ASC, post_reactions.post_id ASC
+LIMIT $5
 `
 
-type DislikePostParams struct {
-	UserID uuid.UUID
-	PostID uuid.UUID
+type ListLikedPostsAscParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
 }
 
-func (q *Queries) DislikePost(ctx context.Context, arg DislikePostParams) error {
-	_, err := q.db.ExecContext(ctx, dislikePost, arg.UserID, arg.PostID)
-	return err
+type ListLikedPostsAscRow struct {
+	Post    Post
+	LikedAt time.Time
 }
 
-const getPostLikes = `-- name: GetPostLikes :one
-SELECT likes from posts
-WHERE id = $1
+func (q *Queries) ListLikedPostsAsc(ctx context.Context, arg ListLikedPostsAscParams) ([]ListLikedPostsAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLikedPostsAsc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLikedPostsAscRow
+	for rows.Next() {
+		var i ListLikedPostsAscRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.UserID,
+			&i.Post.Body,
+			&i.Post.Likes,
+			&i.Post.EditedAt,
+			&i.Post.ParentID,
+			&i.Post.RootID,
+			&i.Post.ReplyCount,
+			&i.Post.DeletedAt,
+			&i.Post.RepostOfID,
+			&i.Post.QuoteOfID,
+			&i.Post.RepostCount,
+			&i.Post.Visibility,
+			&i.Post.ReactionCounts,
+			&i.Post.FannedOut,
+			&i.LikedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLikedPostsDesc = `-- name: ListLikedPostsDesc :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.user_id, posts.body, posts.likes, posts.edited_at, posts.parent_id, posts.root_id, posts.reply_count, posts.deleted_at, posts.repost_of_id, posts.quote_of_id, posts.repost_count, posts.visibility, posts.reaction_counts, posts.fanned_out, post_reactions.created_at AS liked_at FROM post_reactions
+JOIN posts ON posts.id = post_reactions.post_id
+WHERE post_reactions.user_id = $1
+AND post_reactions.reaction = 'like'
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, $2::uuid)
+AND post_visible(posts.repost_of_id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.post_id) < ($3::timestamp, $4::uuid))
+ORDER BY post_reactions.created_at DESC, post_reactions.post_id DESC
+LIMIT $5
 `
 
-func (q *Queries) GetPostLikes(ctx context.Context, id uuid.UUID) (int32, error) {
-	row := q.db.QueryRowContext(ctx, getPostLikes, id)
-	var likes int32
-	err := row.Scan(&likes)
-	return likes, err
+type ListLikedPostsDescParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
 }
 
-const incrementPostLike = `-- name: IncrementPostLike :exec
-UPDATE posts SET likes = likes + 1
-WHERE id = $1
-`
+type ListLikedPostsDescRow struct {
+	Post    Post
+	LikedAt time.Time
+}
 
-func (q *Queries) IncrementPostLike(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, incrementPostLike, id)
-	return err
+func (q *Queries) ListLikedPostsDesc(ctx context.Context, arg ListLikedPostsDescParams) ([]ListLikedPostsDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLikedPostsDesc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLikedPostsDescRow
+	for rows.Next() {
+		var i ListLikedPostsDescRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.UserID,
+			&i.Post.Body,
+			&i.Post.Likes,
+			&i.Post.EditedAt,
+			&i.Post.ParentID,
+			&i.Post.RootID,
+			&i.Post.ReplyCount,
+			&i.Post.DeletedAt,
+			&i.Post.RepostOfID,
+			&i.Post.QuoteOfID,
+			&i.Post.RepostCount,
+			&i.Post.Visibility,
+			&i.Post.ReactionCounts,
+			&i.Post.FannedOut,
+			&i.LikedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const likePost = `-- name: LikePost :one
-INSERT INTO posts_likes (id, post_id, user_id, created_at)
-VALUES (
-   $1,
-   $2,
-   $3,
-   NOW()
-)
-RETURNING id, post_id, user_id, created_at
+const listLikesAsc = `-- name: ListLikesAsc :many
+SELECT id, post_id, user_id, created_at FROM post_reactions
+WHERE reaction = 'like'
+AND ($1::timestamp IS NULL
+   OR (created_at, id) > ($1::timestamp, $2::uuid))
+AND ($3::uuid IS NULL OR post_id = $3::uuid)
+AND ($4::uuid IS NULL OR user_id = $4::uuid)
+AND post_visible(post_id, $5::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $6
 `
 
-type LikePostParams struct {
-	ID     uuid.UUID
-	PostID uuid.UUID
-	UserID uuid.UUID
+type ListLikesAscParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PostID          uuid.NullUUID
+	UserID          uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
 }
 
-func (q *Queries) LikePost(ctx context.Context, arg LikePostParams) (PostsLike, error) {
-	row := q.db.QueryRowContext(ctx, likePost, arg.ID, arg.PostID, arg.UserID)
-	var i PostsLike
-	err := row.Scan(
-		&i.ID,
-		&i.PostID,
-		&i.UserID,
-		&i.CreatedAt,
+type ListLikesAscRow struct {
+	ID        uuid.UUID
+	PostID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListLikesAsc(ctx context.Context, arg ListLikesAscParams) ([]ListLikesAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLikesAsc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PostID,
+		arg.UserID,
+		arg.ViewerID,
+		arg.Limit,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLikesAscRow
+	for rows.Next() {
+		var i ListLikesAscRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.UserID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const listLikePost = `-- name: ListLikePost :many
-SELECT id, post_id, user_id, created_at FROM posts_likes
+const listLikesDesc = `-- name: ListLikesDesc :many
+SELECT id, post_id, user_id, created_at FROM post_reactions
+WHERE reaction = 'like'
+AND ($1::timestamp IS NULL
+   OR (created_at, id) < ($1::timestamp, $2::uuid))
+AND ($3::uuid IS NULL OR post_id = $3::uuid)
+AND ($4::uuid IS NULL OR user_id = $4::uuid)
+AND post_visible(post_id, $5::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
 `
 
-func (q *Queries) ListLikePost(ctx context.Context) ([]PostsLike, error) {
-	rows, err := q.db.QueryContext(ctx, listLikePost)
+type ListLikesDescParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PostID          uuid.NullUUID
+	UserID          uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListLikesDescRow struct {
+	ID        uuid.UUID
+	PostID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListLikesDesc(ctx context.Context, arg ListLikesDescParams) ([]ListLikesDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLikesDesc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PostID,
+		arg.UserID,
+		arg.ViewerID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []PostsLike
+	var items []ListLikesDescRow
 	for rows.Next() {
-		var i PostsLike
+		var i ListLikesDescRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.PostID,
@@ -128,3 +336,138 @@ func (q *Queries) ListLikePost(ctx context.Context) ([]PostsLike, error) {
 	}
 	return items, nil
 }
+
+const listPostLikersAsc = `-- name: ListPostLikersAsc :many
+SELECT users.id, users.username, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = $1
+AND post_reactions.reaction = 'like'
+AND author_visible(post_reactions.user_id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) > ($3::timestamp, $4::uuid))
+ORDER BY post_reactions.created_at ASC, post_reactions.user_id ASC
+LIMIT $5
+`
+
+type ListPostLikersAscParams struct {
+	PostID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListPostLikersAscRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListPostLikersAsc(ctx context.Context, arg ListPostLikersAscParams) ([]ListPostLikersAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostLikersAsc,
+		arg.PostID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostLikersAscRow
+	for rows.Next() {
+		var i ListPostLikersAscRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostLikersDesc = `-- name: ListPostLikersDesc :many
+SELECT users.id, users.username, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = $1
+AND post_reactions.reaction = 'like'
+AND author_visible(post_reactions.user_id, $2::uuid)
+AND ($3::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) < ($3::timestamp, $4::uuid))
+ORDER BY post_reactions.created_at DESC, post_reactions.user_id DESC
+LIMIT $5
+`
+
+type ListPostLikersDescParams struct {
+	PostID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListPostLikersDescRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListPostLikersDesc(ctx context.Context, arg ListPostLikersDescParams) ([]ListPostLikersDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostLikersDesc,
+		arg.PostID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostLikersDescRow
+	for rows.Next() {
+		var i ListPostLikersDescRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recountPostReactions = `-- name: RecountPostReactions :execrows
+UPDATE posts SET reaction_counts = counted.reaction_counts,
+likes = COALESCE((counted.reaction_counts->>'like')::int, 0)
+FROM (
+   SELECT posts.id, COALESCE((
+      SELECT jsonb_object_agg(per_reaction.reaction, per_reaction.count) FROM (
+         SELECT reaction, COUNT(*) AS count FROM post_reactions
+         WHERE post_reactions.post_id = posts.id
+         GROUP BY reaction
+      ) AS per_reaction
+   ), '{}')::jsonb AS reaction_counts FROM posts
+) AS counted
+WHERE posts.id = counted.id
+AND (posts.reaction_counts <> counted.reaction_counts
+   OR posts.likes <> COALESCE((counted.reaction_counts->>'like')::int, 0))
+`
+
+func (q *Queries) RecountPostReactions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recountPostReactions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
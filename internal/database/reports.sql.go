@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -40,12 +41,80 @@ func (q *Queries) GetReportByID(ctx context.Context, reportID uuid.UUID) (Report
 	return i, err
 }
 
-const listAllReports = `-- name: ListAllReports :many
+const listReportsAsc = `-- name: ListReportsAsc :many
 SELECT report_id, created_at, updated_at, post_id, user_id, reason FROM reports
+WHERE ($1::timestamp IS NULL
+   OR (created_at, report_id) > ($1::timestamp, $2::uuid))
+AND ($3::uuid IS NULL OR post_id = $3::uuid)
+ORDER BY created_at ASC, report_id ASC
+LIMIT $4
 `
 
-func (q *Queries) ListAllReports(ctx context.Context) ([]Report, error) {
-	rows, err := q.db.QueryContext(ctx, listAllReports)
+type ListReportsAscParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PostID          uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListReportsAsc(ctx context.Context, arg ListReportsAscParams) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReportsAsc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PostID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Report
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ReportID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PostID,
+			&i.UserID,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReportsDesc = `-- name: ListReportsDesc :many
+SELECT report_id, created_at, updated_at, post_id, user_id, reason FROM reports
+WHERE ($1::timestamp IS NULL
+   OR (created_at, report_id) < ($1::timestamp, $2::uuid))
+AND ($3::uuid IS NULL OR post_id = $3::uuid)
+ORDER BY created_at DESC, report_id DESC
+LIMIT $4
+`
+
+type ListReportsDescParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PostID          uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListReportsDesc(ctx context.Context, arg ListReportsDescParams) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReportsDesc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PostID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -10,7 +10,8 @@ import (
 )
 
 const resetLikePost = `-- name: ResetLikePost :exec
-DELETE FROM posts_likes
+DELETE FROM post_reactions
+WHERE reaction = 'like'
 `
 
 func (q *Queries) ResetLikePost(ctx context.Context) error {
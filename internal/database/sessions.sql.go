@@ -0,0 +1,195 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: sessions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (id, created_at, updated_at, user_id, user_agent, ip_address, device_name, last_used_at, client_id)
+VALUES (
+   $1,
+   NOW(),
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5,
+   NOW(),
+   $6
+)
+RETURNING id, created_at, updated_at, user_id, user_agent, ip_address, device_name, last_used_at, revoked_at, reuse_detected_at, client_id
+`
+
+type CreateSessionParams struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	UserAgent  string
+	IpAddress  string
+	DeviceName sql.NullString
+	ClientID   sql.NullString
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.DeviceName,
+		arg.ClientID,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.DeviceName,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.ReuseDetectedAt,
+		&i.ClientID,
+	)
+	return i, err
+}
+
+const flagSessionReuse = `-- name: FlagSessionReuse :exec
+UPDATE sessions SET revoked_at = COALESCE(revoked_at, NOW()),
+updated_at = NOW(), reuse_detected_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) FlagSessionReuse(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, flagSessionReuse, id)
+	return err
+}
+
+const getSessionByID = `-- name: GetSessionByID :one
+SELECT id, created_at, updated_at, user_id, user_agent, ip_address, device_name, last_used_at, revoked_at, reuse_detected_at, client_id FROM sessions
+WHERE id = $1
+`
+
+func (q *Queries) GetSessionByID(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByID, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.DeviceName,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.ReuseDetectedAt,
+		&i.ClientID,
+	)
+	return i, err
+}
+
+const listActiveSessionsByUser = `-- name: ListActiveSessionsByUser :many
+SELECT id, created_at, updated_at, user_id, user_agent, ip_address, device_name, last_used_at, revoked_at, reuse_detected_at, client_id FROM sessions
+WHERE user_id = $1
+AND revoked_at IS NULL
+ORDER BY last_used_at DESC
+`
+
+func (q *Queries) ListActiveSessionsByUser(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveSessionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.DeviceName,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.ReuseDetectedAt,
+			&i.ClientID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAllSessionsForUser = `-- name: RevokeAllSessionsForUser :exec
+UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAllSessionsForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeAllSessionsForUser, userID)
+	return err
+}
+
+const revokeSession = `-- name: RevokeSession :one
+UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND revoked_at IS NULL
+RETURNING id, created_at, updated_at, user_id, user_agent, ip_address, device_name, last_used_at, revoked_at, reuse_detected_at, client_id
+`
+
+type RevokeSessionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, revokeSession, arg.ID, arg.UserID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.DeviceName,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.ReuseDetectedAt,
+		&i.ClientID,
+	)
+	return i, err
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE sessions SET last_used_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND last_used_at < NOW() - INTERVAL '1 minute'
+`
+
+func (q *Queries) TouchSession(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchSession, id)
+	return err
+}
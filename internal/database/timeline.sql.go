@@ -0,0 +1,356 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: timeline.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const backfillTimeline = `-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+SELECT $1::uuid, recent.id, recent.user_id, recent.created_at
+FROM (
+   SELECT id, user_id, created_at FROM posts
+   WHERE posts.user_id = $2::uuid
+   AND parent_id IS NULL
+   AND deleted_at IS NULL
+   ORDER BY created_at DESC
+   LIMIT $3
+) AS recent
+ON CONFLICT DO NOTHING
+`
+
+type BackfillTimelineParams struct {
+	UserID   uuid.UUID
+	AuthorID uuid.UUID
+	Limit    int32
+}
+
+func (q *Queries) BackfillTimeline(ctx context.Context, arg BackfillTimelineParams) error {
+	_, err := q.db.ExecContext(ctx, backfillTimeline, arg.UserID, arg.AuthorID, arg.Limit)
+	return err
+}
+
+const deleteTimelineEntriesByAuthor = `-- name: DeleteTimelineEntriesByAuthor :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1
+AND author_id = $2
+`
+
+type DeleteTimelineEntriesByAuthorParams struct {
+	UserID   uuid.UUID
+	AuthorID uuid.UUID
+}
+
+func (q *Queries) DeleteTimelineEntriesByAuthor(ctx context.Context, arg DeleteTimelineEntriesByAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTimelineEntriesByAuthor, arg.UserID, arg.AuthorID)
+	return err
+}
+
+const fanOutPost = `-- name: FanOutPost :exec
+WITH fanned_out AS (
+   UPDATE posts SET fanned_out = TRUE
+   WHERE id = $1::uuid
+)
+INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+SELECT follows.follower_id, $1::uuid, $2::uuid, $3::timestamp
+FROM follows
+WHERE follows.followee_id = $2::uuid
+ON CONFLICT DO NOTHING
+`
+
+type FanOutPostParams struct {
+	PostID    uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+func (q *Queries) FanOutPost(ctx context.Context, arg FanOutPostParams) error {
+	_, err := q.db.ExecContext(ctx, fanOutPost, arg.PostID, arg.AuthorID, arg.CreatedAt)
+	return err
+}
+
+const listTimelineAsc = `-- name: ListTimelineAsc :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.user_id, posts.body, posts.likes, posts.edited_at, posts.parent_id, posts.root_id, posts.reply_count, posts.deleted_at, posts.repost_of_id, posts.quote_of_id, posts.repost_count, posts.visibility, posts.reaction_counts, posts.fanned_out FROM timeline_entries
+JOIN posts ON posts.id = timeline_entries.post_id
+WHERE timeline_entries.user_id = $1
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, $1::uuid)
+AND post_visible(posts.repost_of_id, $1::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1::uuid AND muted_id = posts.user_id)
+AND ($2::timestamp IS NULL
+   OR (timeline_entries.created_at, timeline_entries.post_id) > ($2::timestamp, $3::uuid))
+ORDER BY timeline_entries.created_at ASC, timeline_entries.post_id ASC
+LIMIT $4
+`
+
+type ListTimelineAscParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListTimelineAscRow struct {
+	Post Post
+}
+
+func (q *Queries) ListTimelineAsc(ctx context.Context, arg ListTimelineAscParams) ([]ListTimelineAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTimelineAsc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTimelineAscRow
+	for rows.Next() {
+		var i ListTimelineAscRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.UserID,
+			&i.Post.Body,
+			&i.Post.Likes,
+			&i.Post.EditedAt,
+			&i.Post.ParentID,
+			&i.Post.RootID,
+			&i.Post.ReplyCount,
+			&i.Post.DeletedAt,
+			&i.Post.RepostOfID,
+			&i.Post.QuoteOfID,
+			&i.Post.RepostCount,
+			&i.Post.Visibility,
+			&i.Post.ReactionCounts,
+			&i.Post.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimelineDesc = `-- name: ListTimelineDesc :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.user_id, posts.body, posts.likes, posts.edited_at, posts.parent_id, posts.root_id, posts.reply_count, posts.deleted_at, posts.repost_of_id, posts.quote_of_id, posts.repost_count, posts.visibility, posts.reaction_counts, posts.fanned_out FROM timeline_entries
+JOIN posts ON posts.id = timeline_entries.post_id
+WHERE timeline_entries.user_id = $1
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, $1::uuid)
+AND post_visible(posts.repost_of_id, $1::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1::uuid AND muted_id = posts.user_id)
+AND ($2::timestamp IS NULL
+   OR (timeline_entries.created_at, timeline_entries.post_id) < ($2::timestamp, $3::uuid))
+ORDER BY timeline_entries.created_at DESC, timeline_entries.post_id DESC
+LIMIT $4
+`
+
+type ListTimelineDescParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListTimelineDescRow struct {
+	Post Post
+}
+
+func (q *Queries) ListTimelineDesc(ctx context.Context, arg ListTimelineDescParams) ([]ListTimelineDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTimelineDesc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTimelineDescRow
+	for rows.Next() {
+		var i ListTimelineDescRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.UserID,
+			&i.Post.Body,
+			&i.Post.Likes,
+			&i.Post.EditedAt,
+			&i.Post.ParentID,
+			&i.Post.RootID,
+			&i.Post.ReplyCount,
+			&i.Post.DeletedAt,
+			&i.Post.RepostOfID,
+			&i.Post.QuoteOfID,
+			&i.Post.RepostCount,
+			&i.Post.Visibility,
+			&i.Post.ReactionCounts,
+			&i.Post.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnfannedTimelinePostsAsc = `-- name: ListUnfannedTimelinePostsAsc :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE (user_id = $1::uuid
+   OR (NOT fanned_out AND user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1::uuid)))
+AND parent_id IS NULL
+AND deleted_at IS NULL
+AND post_visible(posts.id, $1::uuid)
+AND post_visible(posts.repost_of_id, $1::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1::uuid AND muted_id = posts.user_id)
+AND ($2::timestamp IS NULL
+   OR (created_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type ListUnfannedTimelinePostsAscParams struct {
+	ViewerID        uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListUnfannedTimelinePostsAsc(ctx context.Context, arg ListUnfannedTimelinePostsAscParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listUnfannedTimelinePostsAsc,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnfannedTimelinePostsDesc = `-- name: ListUnfannedTimelinePostsDesc :many
+SELECT id, created_at, updated_at, user_id, body, likes, edited_at, parent_id, root_id, reply_count, deleted_at, repost_of_id, quote_of_id, repost_count, visibility, reaction_counts, fanned_out FROM posts
+WHERE (user_id = $1::uuid
+   OR (NOT fanned_out AND user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1::uuid)))
+AND parent_id IS NULL
+AND deleted_at IS NULL
+AND post_visible(posts.id, $1::uuid)
+AND post_visible(posts.repost_of_id, $1::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1::uuid AND muted_id = posts.user_id)
+AND ($2::timestamp IS NULL
+   OR (created_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListUnfannedTimelinePostsDescParams struct {
+	ViewerID        uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListUnfannedTimelinePostsDesc(ctx context.Context, arg ListUnfannedTimelinePostsDescParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listUnfannedTimelinePostsDesc,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.Likes,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.RepostCount,
+			&i.Visibility,
+			&i.ReactionCounts,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
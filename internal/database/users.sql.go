@@ -7,25 +7,32 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const changeUser = `-- name: ChangeUser :one
-UPDATE users SET email = $1, updated_at = NOW(), password = $2
-WHERE id = $3
-RETURNING id, created_at, updated_at, email, username, password, is_premium
+UPDATE users SET pending_email = $1, updated_at = NOW(), password = $2, is_private = $3
+WHERE id = $4
+RETURNING id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private
 `
 
 type ChangeUserParams struct {
-	Email    string
-	Password string
-	ID       uuid.UUID
+	PendingEmail sql.NullString
+	Password     string
+	IsPrivate    bool
+	ID           uuid.UUID
 }
 
 func (q *Queries) ChangeUser(ctx context.Context, arg ChangeUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, changeUser, arg.Email, arg.Password, arg.ID)
+	row := q.db.QueryRowContext(ctx, changeUser,
+		arg.PendingEmail,
+		arg.Password,
+		arg.IsPrivate,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -35,6 +42,15 @@ func (q *Queries) ChangeUser(ctx context.Context, arg ChangeUserParams) (User, e
 		&i.Username,
 		&i.Password,
 		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
 	)
 	return i, err
 }
@@ -56,6 +72,43 @@ func (q *Queries) CheckIfUsernameOrEmailTaken(ctx context.Context, arg CheckIfUs
 	return id, err
 }
 
+const confirmUserEmail = `-- name: ConfirmUserEmail :one
+UPDATE users SET email = $1, pending_email = NULL,
+email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $2
+AND (email = $1 OR pending_email = $1)
+RETURNING id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private
+`
+
+type ConfirmUserEmailParams struct {
+	Email string
+	ID    uuid.UUID
+}
+
+func (q *Queries) ConfirmUserEmail(ctx context.Context, arg ConfirmUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, confirmUserEmail, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.Username,
+		&i.Password,
+		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, username, password)
 VALUES (
@@ -66,7 +119,7 @@ VALUES (
    $3,
    $4
 )
-RETURNING id, created_at, updated_at, email, username, password, is_premium
+RETURNING id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private
 `
 
 type CreateUserParams struct {
@@ -92,12 +145,47 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Username,
 		&i.Password,
 		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL,
+totp_last_used_step = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, id)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :exec
+UPDATE users SET totp_enabled_at = NOW(), totp_last_used_step = $1, updated_at = NOW()
+WHERE id = $2
+`
+
+type EnableTOTPParams struct {
+	TotpLastUsedStep sql.NullInt64
+	ID               uuid.UUID
+}
+
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, enableTOTP, arg.TotpLastUsedStep, arg.ID)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, username, password, is_premium FROM users
+SELECT id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private FROM users
 WHERE email = $1
 `
 
@@ -112,12 +200,21 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Username,
 		&i.Password,
 		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, username, password, is_premium FROM users
+SELECT id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private FROM users
 WHERE id = $1
 `
 
@@ -132,12 +229,21 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Username,
 		&i.Password,
 		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, created_at, updated_at, email, username, password, is_premium FROM users
+SELECT id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private FROM users
 WHERE username = $1
 `
 
@@ -152,15 +258,85 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Username,
 		&i.Password,
 		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
-const listAllUsers = `-- name: ListAllUsers :many
+const listUsersAsc = `-- name: ListUsersAsc :many
+SELECT id, created_at, updated_at, email, username FROM users
+WHERE ($1::timestamp IS NULL
+   OR (created_at, id) > ($1::timestamp, $2::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $3
+`
+
+type ListUsersAscParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListUsersAscRow struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Email     string
+	Username  string
+}
+
+func (q *Queries) ListUsersAsc(ctx context.Context, arg ListUsersAscParams) ([]ListUsersAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersAsc, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersAscRow
+	for rows.Next() {
+		var i ListUsersAscRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDesc = `-- name: ListUsersDesc :many
 SELECT id, created_at, updated_at, email, username FROM users
+WHERE ($1::timestamp IS NULL
+   OR (created_at, id) < ($1::timestamp, $2::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3
 `
 
-type ListAllUsersRow struct {
+type ListUsersDescParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type ListUsersDescRow struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -168,15 +344,15 @@ type ListAllUsersRow struct {
 	Username  string
 }
 
-func (q *Queries) ListAllUsers(ctx context.Context) ([]ListAllUsersRow, error) {
-	rows, err := q.db.QueryContext(ctx, listAllUsers)
+func (q *Queries) ListUsersDesc(ctx context.Context, arg ListUsersDescParams) ([]ListUsersDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersDesc, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListAllUsersRow
+	var items []ListUsersDescRow
 	for rows.Next() {
-		var i ListAllUsersRow
+		var i ListUsersDescRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -197,10 +373,79 @@ func (q *Queries) ListAllUsers(ctx context.Context) ([]ListAllUsersRow, error) {
 	return items, nil
 }
 
+const setTOTPSecret = `-- name: SetTOTPSecret :execrows
+UPDATE users SET totp_secret = $1, updated_at = NOW()
+WHERE id = $2
+AND totp_enabled_at IS NULL
+`
+
+type SetTOTPSecretParams struct {
+	TotpSecret sql.NullString
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTOTPSecret, arg.TotpSecret, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users SET role = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private
+`
+
+type SetUserRoleParams struct {
+	Role string
+	ID   uuid.UUID
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.Role, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.Username,
+		&i.Password,
+		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET password = $1, updated_at = NOW()
+WHERE id = $2
+`
+
+type UpdateUserPasswordParams struct {
+	Password string
+	ID       uuid.UUID
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.Password, arg.ID)
+	return err
+}
+
 const upgradeToPremium = `-- name: UpgradeToPremium :one
 UPDATE users SET is_premium = true, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, username, password, is_premium
+RETURNING id, created_at, updated_at, email, username, password, is_premium, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_used_step, follower_count, following_count, is_private
 `
 
 func (q *Queries) UpgradeToPremium(ctx context.Context, id uuid.UUID) (User, error) {
@@ -214,6 +459,34 @@ func (q *Queries) UpgradeToPremium(ctx context.Context, id uuid.UUID) (User, err
 		&i.Username,
 		&i.Password,
 		&i.IsPremium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.IsPrivate,
 	)
 	return i, err
 }
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users SET totp_last_used_step = $1
+WHERE id = $2
+AND (totp_last_used_step IS NULL OR totp_last_used_step < $1)
+`
+
+type UseTOTPStepParams struct {
+	TotpLastUsedStep sql.NullInt64
+	ID               uuid.UUID
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.TotpLastUsedStep, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - delivers messages to users, swap the implementation to change the provider
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer - prints messages to the log instead of sending them, for local development
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer - writes every message as a separate file into Dir, for local development and tests
+type FileMailer struct {
+	Dir string
+}
+
+func (m FileMailer) Send(ctx context.Context, msg Message) error {
+	err := os.MkdirAll(m.Dir, 0o750)
+	if err != nil {
+		return fmt.Errorf("can't create mail dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.NewString())
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", msg.To, msg.Subject, msg.Body)
+
+	err = os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o600)
+	if err != nil {
+		return fmt.Errorf("can't write mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerSend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m := FileMailer{Dir: dir}
+
+	messages := []Message{
+		{To: "first@example.com", Subject: "Hello", Body: "first body"},
+		{To: "second@example.com", Subject: "Hello again", Body: "second body"},
+	}
+	for _, msg := range messages {
+		if err := m.Send(context.Background(), msg); err != nil {
+			t.Fatalf("FileMailer.Send() error = %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("can't read outbox: %v", err)
+	}
+	if len(entries) != len(messages) {
+		t.Fatalf("FileMailer.Send() wrote %d files, want %d", len(entries), len(messages))
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("can't read mail: %v", err)
+	}
+	for _, want := range []string{"To: first@example.com", "Subject: Hello", "first body"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("mail content %q doesn't contain %q", content, want)
+		}
+	}
+}
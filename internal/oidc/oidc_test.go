@@ -0,0 +1,146 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/imhasandl/go-restapi/internal/oidc"
+	"github.com/imhasandl/go-restapi/internal/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:8080/api/auth/oidc/mock/callback"
+
+func newProvider(t *testing.T) *oidctest.Provider {
+	t.Helper()
+	provider, err := oidctest.NewProvider("client-id", "client-secret")
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	provider := newProvider(t)
+	provider.SetIdentity(oidc.Identity{
+		Subject:       "user-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Name:          "User One",
+	})
+	client := oidc.NewClient(provider.Config("mock", redirectURL), nil)
+	ctx := context.Background()
+
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatalf("NewCodeVerifier() error = %v", err)
+	}
+	authURL, err := client.AuthCodeURL(ctx, "the-state", "the-nonce", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	if got := parsed.Query().Get("code_challenge"); got != oidc.CodeChallenge(verifier) {
+		t.Errorf("AuthCodeURL() code_challenge = %v, want %v", got, oidc.CodeChallenge(verifier))
+	}
+
+	code, state, err := provider.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if state != "the-state" {
+		t.Errorf("Authorize() state = %v, want the-state", state)
+	}
+
+	identity, err := client.Exchange(ctx, code, verifier, "the-nonce")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := oidc.Identity{Subject: "user-1", Email: "user@example.com", EmailVerified: true, Name: "User One"}
+	if identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", identity, want)
+	}
+
+	if _, err := client.Exchange(ctx, code, verifier, "the-nonce"); err == nil {
+		t.Errorf("Exchange() accepted a code twice")
+	}
+}
+
+func TestExchangeRejectsBadTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     func(claims jwt.MapClaims)
+		verifier string
+		nonce    string
+	}{
+		{
+			name:  "Wrong nonce",
+			nonce: "other-nonce",
+		},
+		{
+			name:     "Wrong code verifier",
+			verifier: "other-verifier",
+		},
+		{
+			name: "Wrong audience",
+			hook: func(claims jwt.MapClaims) { claims["aud"] = "other-client" },
+		},
+		{
+			name: "Wrong issuer",
+			hook: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+		},
+		{
+			name: "Expired",
+			hook: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		},
+		{
+			name: "No expiry",
+			hook: func(claims jwt.MapClaims) { delete(claims, "exp") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newProvider(t)
+			provider.ClaimsHook = tt.hook
+			client := oidc.NewClient(provider.Config("mock", redirectURL), nil)
+			ctx := context.Background()
+
+			verifier, _ := oidc.NewCodeVerifier()
+			authURL, err := client.AuthCodeURL(ctx, "state", "nonce", verifier)
+			if err != nil {
+				t.Fatalf("AuthCodeURL() error = %v", err)
+			}
+			code, _, err := provider.Authorize(authURL)
+			if err != nil {
+				t.Fatalf("Authorize() error = %v", err)
+			}
+
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			nonce := "nonce"
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if _, err := client.Exchange(ctx, code, verifier, nonce); err == nil {
+				t.Errorf("Exchange() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	provider := newProvider(t)
+	config := provider.Config("mock", redirectURL)
+	config.Issuer = provider.Issuer() + "/"
+
+	client := oidc.NewClient(config, nil)
+	if _, err := client.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Errorf("AuthCodeURL() error = nil for a mismatched issuer")
+	}
+}
@@ -0,0 +1,181 @@
+// Package pagination implements keyset pagination on (created_at, id) with
+// opaque cursors.
+package pagination
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor - position of a row in a list ordered by (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode - returns the cursor as an opaque url safe string
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor - parses a cursor made by Encode
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	return Cursor{CreatedAt: t, ID: parsedID}, nil
+}
+
+// Params - a page request. Lists are newest first unless Oldest is set.
+// After continues the list past the cursor, Before goes back towards its start.
+type Params struct {
+	Limit    int32
+	Cursor   *Cursor
+	Backward bool
+	Oldest   bool
+}
+
+// ParseParams - reads limit, after, before and order (newest or oldest)
+// from the query string
+func ParseParams(query url.Values) (Params, error) {
+	params := Params{Limit: DefaultLimit}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return Params{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		params.Limit = int32(n)
+	}
+
+	after, before := query.Get("after"), query.Get("before")
+	if after != "" && before != "" {
+		return Params{}, errors.New("after and before can't be used together")
+	}
+	if after != "" || before != "" {
+		cursor, err := DecodeCursor(after + before)
+		if err != nil {
+			return Params{}, err
+		}
+		params.Cursor = &cursor
+		params.Backward = before != ""
+	}
+
+	switch query.Get("order") {
+	case "", "newest":
+	case "oldest":
+		params.Oldest = true
+	default:
+		return Params{}, errors.New("order must be newest or oldest")
+	}
+
+	return params, nil
+}
+
+// Descending - whether rows have to be fetched by descending (created_at, id),
+// starting right below the cursor. Otherwise they are fetched ascending,
+// starting right above it.
+func (p Params) Descending() bool {
+	return p.Oldest == p.Backward
+}
+
+// FetchLimit - one row more than the page holds, to know if there are more
+func (p Params) FetchLimit() int32 {
+	return p.Limit + 1
+}
+
+// CursorCreatedAt and CursorID - the cursor as nullable query arguments
+func (p Params) CursorCreatedAt() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+func (p Params) CursorID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// Page - the response envelope of every list endpoint
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+// NewPage - builds a page from rows fetched with FetchLimit in the direction
+// given by Descending. cursorOf returns the position of an item.
+func NewPage[T any](items []T, p Params, cursorOf func(T) Cursor) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	hasMore := len(items) > int(p.Limit)
+	if hasMore {
+		items = items[:p.Limit]
+	}
+	if p.Backward {
+		slices.Reverse(items)
+	}
+
+	page := Page[T]{Data: items}
+
+	if len(items) == 0 {
+		// Nothing in this direction, but the way back from the cursor is still open
+		if p.Cursor != nil {
+			cursor := p.Cursor.Encode()
+			if p.Backward {
+				page.NextCursor = &cursor
+			} else {
+				page.PrevCursor = &cursor
+			}
+		}
+		return page
+	}
+
+	// Going back from a cursor always leaves the cursor's row ahead
+	if hasMore || p.Backward {
+		next := cursorOf(items[len(items)-1]).Encode()
+		page.NextCursor = &next
+	}
+	if hasMore && p.Backward || !p.Backward && p.Cursor != nil {
+		prev := cursorOf(items[0]).Encode()
+		page.PrevCursor = &prev
+	}
+
+	return page
+}
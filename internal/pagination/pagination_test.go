@@ -0,0 +1,212 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("DecodeCursor() = %v, want %v", decoded, cursor)
+	}
+}
+
+func TestDecodeCursorMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "Not base64", cursor: "!!!"},
+		{name: "No separator", cursor: "bm9zZXBhcmF0b3I"},
+		{name: "Bad time", cursor: base64.RawURLEncoding.EncodeToString([]byte("yesterday|" + uuid.NewString()))},
+		{name: "Bad id", cursor: base64.RawURLEncoding.EncodeToString([]byte("2024-05-01T12:30:00Z|42"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.cursor)
+			if err == nil {
+				t.Errorf("DecodeCursor(%q) error = nil, want error", tt.cursor)
+			}
+		})
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}.Encode()
+
+	tests := []struct {
+		name           string
+		query          url.Values
+		wantLimit      int32
+		wantBackward   bool
+		wantDescending bool
+		wantCursor     bool
+		wantErr        bool
+	}{
+		{
+			name:           "Defaults",
+			query:          url.Values{},
+			wantLimit:      DefaultLimit,
+			wantDescending: true,
+		},
+		{
+			name:           "After",
+			query:          url.Values{"after": {cursor}, "limit": {"5"}},
+			wantLimit:      5,
+			wantDescending: true,
+			wantCursor:     true,
+		},
+		{
+			name:         "Before",
+			query:        url.Values{"before": {cursor}},
+			wantLimit:    DefaultLimit,
+			wantBackward: true,
+			wantCursor:   true,
+		},
+		{
+			name:           "Before oldest first",
+			query:          url.Values{"before": {cursor}, "order": {"oldest"}},
+			wantLimit:      DefaultLimit,
+			wantBackward:   true,
+			wantDescending: true,
+			wantCursor:     true,
+		},
+		{
+			name:    "Limit too large",
+			query:   url.Values{"limit": {"101"}},
+			wantErr: true,
+		},
+		{
+			name:    "Limit not a number",
+			query:   url.Values{"limit": {"ten"}},
+			wantErr: true,
+		},
+		{
+			name:    "After and before",
+			query:   url.Values{"after": {cursor}, "before": {cursor}},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown order",
+			query:   url.Values{"order": {"popular"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := ParseParams(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if params.Limit != tt.wantLimit {
+				t.Errorf("Limit = %d, want %d", params.Limit, tt.wantLimit)
+			}
+			if params.Backward != tt.wantBackward {
+				t.Errorf("Backward = %v, want %v", params.Backward, tt.wantBackward)
+			}
+			if params.Descending() != tt.wantDescending {
+				t.Errorf("Descending() = %v, want %v", params.Descending(), tt.wantDescending)
+			}
+			if (params.Cursor != nil) != tt.wantCursor {
+				t.Errorf("Cursor = %v, want cursor %v", params.Cursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]Cursor, 5)
+	for i := range rows {
+		rows[i] = Cursor{CreatedAt: base.Add(time.Duration(i) * time.Minute), ID: uuid.New()}
+	}
+	identity := func(c Cursor) Cursor { return c }
+	middle := rows[2]
+
+	tests := []struct {
+		name      string
+		fetched   []Cursor
+		params    Params
+		wantFirst Cursor
+		wantLen   int
+		wantNext  bool
+		wantPrev  bool
+	}{
+		{
+			name:      "First page with more",
+			fetched:   []Cursor{rows[4], rows[3], rows[2]},
+			params:    Params{Limit: 2},
+			wantFirst: rows[4],
+			wantLen:   2,
+			wantNext:  true,
+		},
+		{
+			name:      "Last page after a cursor",
+			fetched:   []Cursor{rows[1], rows[0]},
+			params:    Params{Limit: 2, Cursor: &middle},
+			wantFirst: rows[1],
+			wantLen:   2,
+			wantPrev:  true,
+		},
+		{
+			name:      "Before a cursor is reversed",
+			fetched:   []Cursor{rows[3], rows[4]},
+			params:    Params{Limit: 2, Cursor: &middle, Backward: true},
+			wantFirst: rows[4],
+			wantLen:   2,
+			wantNext:  true,
+		},
+		{
+			name:     "Empty after a cursor",
+			fetched:  nil,
+			params:   Params{Limit: 2, Cursor: &middle},
+			wantLen:  0,
+			wantPrev: true,
+		},
+		{
+			name:    "Empty list",
+			fetched: nil,
+			params:  Params{Limit: 2},
+			wantLen: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(tt.fetched, tt.params, identity)
+			if page.Data == nil {
+				t.Fatal("Data is nil, want an empty slice")
+			}
+			if len(page.Data) != tt.wantLen {
+				t.Fatalf("len(Data) = %d, want %d", len(page.Data), tt.wantLen)
+			}
+			if tt.wantLen > 0 && page.Data[0] != tt.wantFirst {
+				t.Errorf("Data[0] = %v, want %v", page.Data[0], tt.wantFirst)
+			}
+			if (page.NextCursor != nil) != tt.wantNext {
+				t.Errorf("NextCursor = %v, want cursor %v", page.NextCursor, tt.wantNext)
+			}
+			if (page.PrevCursor != nil) != tt.wantPrev {
+				t.Errorf("PrevCursor = %v, want cursor %v", page.PrevCursor, tt.wantPrev)
+			}
+		})
+	}
+}
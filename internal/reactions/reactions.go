@@ -0,0 +1,67 @@
+package reactions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Reaction - a kind of reaction users can leave on a post
+type Reaction struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji"`
+}
+
+// Set - the reactions a deployment offers, in the order they are shown
+type Set struct {
+	reactions []Reaction
+	byName    map[string]Reaction
+}
+
+// DefaultReactions - used when the deployment doesn't configure its own set
+const DefaultReactions = "like=👍,love=❤️,laugh=😂,sad=😢,angry=😠"
+
+// Like - the reaction behind likes, every set has it
+const Like = "like"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// ParseSet - reads a comma separated list of name=emoji pairs, like
+// DefaultReactions. Names are lowercase letters, digits and underscores, and
+// the set has to include Like.
+func ParseSet(value string) (Set, error) {
+	set := Set{byName: map[string]Reaction{}}
+	for _, entry := range strings.Split(value, ",") {
+		name, emoji, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.TrimSpace(name)
+		emoji = strings.TrimSpace(emoji)
+		if !ok || emoji == "" {
+			return Set{}, fmt.Errorf("reaction %q must look like name=emoji", entry)
+		}
+		if !namePattern.MatchString(name) {
+			return Set{}, fmt.Errorf("invalid reaction name: %q", name)
+		}
+		if _, ok := set.byName[name]; ok {
+			return Set{}, fmt.Errorf("duplicate reaction: %q", name)
+		}
+
+		reaction := Reaction{Name: name, Emoji: emoji}
+		set.reactions = append(set.reactions, reaction)
+		set.byName[name] = reaction
+	}
+	if !set.Has(Like) {
+		return Set{}, fmt.Errorf("reactions must include %q, likes are that reaction", Like)
+	}
+	return set, nil
+}
+
+// Has - reports whether name is one of the reactions in the set
+func (s Set) Has(name string) bool {
+	_, ok := s.byName[name]
+	return ok
+}
+
+// List - the reactions of the set in their configured order
+func (s Set) List() []Reaction {
+	return append([]Reaction{}, s.reactions...)
+}
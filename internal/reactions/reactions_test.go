@@ -0,0 +1,61 @@
+package reactions
+
+import "testing"
+
+func TestParseSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantLen int
+		wantHas string
+		wantErr bool
+	}{
+		{name: "Default", value: DefaultReactions, wantLen: 5, wantHas: "laugh"},
+		{name: "Custom with spaces", value: " like = 👍 , party=🎉", wantLen: 2, wantHas: "party"},
+		{name: "Missing emoji", value: "love=", wantErr: true},
+		{name: "Missing separator", value: "love", wantErr: true},
+		{name: "Empty", value: "", wantErr: true},
+		{name: "Uppercase name", value: "Love=❤️", wantErr: true},
+		{name: "Duplicate", value: "love=❤️,love=😍", wantErr: true},
+		{name: "Without like", value: "love=❤️,party=🎉", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseSet(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSet(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(set.List()) != tt.wantLen {
+				t.Errorf("len(List()) = %d, want %d", len(set.List()), tt.wantLen)
+			}
+			if !set.Has(tt.wantHas) {
+				t.Errorf("Has(%q) = false, want true", tt.wantHas)
+			}
+			if set.Has("unknown") {
+				t.Error("Has(\"unknown\") = true, want false")
+			}
+		})
+	}
+}
+
+func TestListKeepsOrder(t *testing.T) {
+	set, err := ParseSet("b=🅱️,like=👍,a=🅰️")
+	if err != nil {
+		t.Fatalf("ParseSet() error = %v", err)
+	}
+
+	list := set.List()
+	if list[0].Name != "b" || list[1].Name != "like" || list[2].Name != "a" {
+		t.Errorf("List() = %v, want b, like, a", list)
+	}
+
+	// Changing the returned slice doesn't change the set
+	list[0].Name = "changed"
+	if set.List()[0].Name != "b" {
+		t.Error("List() returned the set's own slice")
+	}
+}
@@ -0,0 +1,58 @@
+package visibility
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Level - who can see a post
+type Level string
+
+const (
+	// Public - everyone, in every list
+	Public Level = "public"
+	// Unlisted - everyone who has the link, but left out of the public lists
+	Unlisted Level = "unlisted"
+	// Followers - only the author's followers
+	Followers Level = "followers"
+	// Mentioned - only the users mentioned in the post
+	Mentioned Level = "mentioned"
+)
+
+// Parse - checks that level is one of the known levels, an empty level is
+// Public
+func Parse(level string) (Level, error) {
+	switch l := Level(level); l {
+	case "":
+		return Public, nil
+	case Public, Unlisted, Followers, Mentioned:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown visibility: %q", level)
+	}
+}
+
+// Shareable - only posts everyone can see can be reposted or quoted
+func (l Level) Shareable() bool {
+	return l == Public || l == Unlisted
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]+)`)
+
+// Mentions - the usernames mentioned as @username in body, each once and in
+// the order they first appear. Dots and dashes that end a sentence aren't part
+// of the name.
+func Mentions(body string) []string {
+	usernames := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
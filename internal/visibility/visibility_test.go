@@ -0,0 +1,60 @@
+package visibility
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		want    Level
+		wantErr bool
+	}{
+		{name: "Empty is public", level: "", want: Public},
+		{name: "Public", level: "public", want: Public},
+		{name: "Unlisted", level: "unlisted", want: Unlisted},
+		{name: "Followers", level: "followers", want: Followers},
+		{name: "Mentioned", level: "mentioned", want: Mentioned},
+		{name: "Unknown", level: "friends", wantErr: true},
+		{name: "Wrong case", level: "Public", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.level, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "None", body: "hello world", want: []string{}},
+		{name: "One", body: "hi @alice", want: []string{"alice"}},
+		{name: "Several in order", body: "@bob and @alice, meet @carol", want: []string{"bob", "alice", "carol"}},
+		{name: "Repeated", body: "@alice @bob @alice", want: []string{"alice", "bob"}},
+		{name: "End of sentence", body: "thanks @john.doe.", want: []string{"john.doe"}},
+		{name: "Email isn't a mention", body: "mail me at me@example.com", want: []string{}},
+		{name: "Lone at sign", body: "meet @ noon", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Mentions(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
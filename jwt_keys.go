@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imhasandl/go-restapi/internal/auth"
+)
+
+const defaultJWTAudience = "social-media-go"
+
+// loadJWTKeys - signs with the private key in JWT_PRIVATE_KEY_FILE when it is
+// set, otherwise falls back to HS256 with JWT_SECRET. Public keys listed in
+// JWT_VERIFY_KEY_FILES stay valid for verification after a key rotation.
+func loadJWTKeys() (*auth.KeySet, error) {
+	audience := os.Getenv("JWT_AUDIENCE")
+	if audience == "" {
+		audience = defaultJWTAudience
+	}
+
+	privateKeyFile := os.Getenv("JWT_PRIVATE_KEY_FILE")
+	if privateKeyFile == "" {
+		jwtSecret := os.Getenv("JWT_SECRET")
+		if jwtSecret == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE or JWT_SECRET environment variable should be set")
+		}
+		return auth.NewHMACKeySet(jwtSecret, audience)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(privateKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("can't read private key: %w", err)
+	}
+	signer, err := auth.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := auth.NewKeySet(signer, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("can't read verification key %s: %w", file, err)
+		}
+		public, err := auth.ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse verification key %s: %w", file, err)
+		}
+		if err := keys.AddVerificationKey(public); err != nil {
+			return nil, fmt.Errorf("can't use verification key %s: %w", file, err)
+		}
+	}
+
+	return keys, nil
+}
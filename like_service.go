@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/reactions"
+)
+
+// likeService - likes and unlikes posts. A like is the like reaction, so
+// liking replaces another reaction of the user on the post and the likes
+// counter changes with the reaction counts.
+type likeService struct {
+	reactions reactionService
+}
+
+func newLikeService(reactions reactionService) likeService {
+	return likeService{reactions: reactions}
+}
+
+// Like - userID likes postID. Liking a post again returns the existing like
+// with created false. A missing post is sql.ErrNoRows.
+func (s likeService) Like(ctx context.Context, postID, userID uuid.UUID) (like database.PostReaction, created bool, err error) {
+	like, previous, err := s.reactions.React(ctx, postID, userID, reactions.Like)
+	return like, previous != reactions.Like, err
+}
+
+// Unlike - removes the like of userID from postID. Unliking a post that isn't
+// liked, or doesn't exist anymore, does nothing and returns removed false.
+// Another reaction of the user stays.
+func (s likeService) Unlike(ctx context.Context, postID, userID uuid.UUID) (removed bool, err error) {
+	return s.reactions.Unreact(ctx, postID, userID, reactions.Like)
+}
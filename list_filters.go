@@ -0,0 +1,38 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// queryUUID - optional uuid filter from the query string
+func queryUUID(r *http.Request, name string) (uuid.NullUUID, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("%s must be a uuid", name)
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+// queryTime - optional RFC 3339 time filter from the query string
+func queryTime(r *http.Request, name string) (sql.NullTime, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
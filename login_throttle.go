@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+var (
+	accountLockoutPolicy = auth.LockoutPolicy{
+		Threshold:  5,
+		BaseDelay:  30 * time.Second,
+		MaxDelay:   15 * time.Minute,
+		ResetAfter: 15 * time.Minute,
+	}
+	// One address can be shared by many users (offices, mobile carriers), so it gets more room
+	ipLockoutPolicy = auth.LockoutPolicy{
+		Threshold:  20,
+		BaseDelay:  30 * time.Second,
+		MaxDelay:   time.Hour,
+		ResetAfter: time.Hour,
+	}
+	// Every reset request sends a mail, so they are limited whether they fail or not
+	passwordResetAccountPolicy = auth.LockoutPolicy{
+		Threshold:  3,
+		BaseDelay:  5 * time.Minute,
+		MaxDelay:   time.Hour,
+		ResetAfter: time.Hour,
+	}
+	passwordResetIPPolicy = auth.LockoutPolicy{
+		Threshold:  20,
+		BaseDelay:  time.Minute,
+		MaxDelay:   time.Hour,
+		ResetAfter: time.Hour,
+	}
+)
+
+type loginThrottle struct {
+	key    string
+	policy auth.LockoutPolicy
+}
+
+func accountThrottle(email string) loginThrottle {
+	return loginThrottle{
+		key:    "email:" + strings.ToLower(strings.TrimSpace(email)),
+		policy: accountLockoutPolicy,
+	}
+}
+
+func ipThrottle(r *http.Request) loginThrottle {
+	return loginThrottle{
+		key:    "ip:" + clientIP(r),
+		policy: ipLockoutPolicy,
+	}
+}
+
+func mfaThrottle(userID uuid.UUID) loginThrottle {
+	return loginThrottle{
+		key:    "mfa:" + userID.String(),
+		policy: accountLockoutPolicy,
+	}
+}
+
+// passwordResetThrottles - reset requests are counted apart from logins, so
+// asking for resets can't lock the owner out of logging in
+func passwordResetThrottles(email string, r *http.Request) []loginThrottle {
+	return []loginThrottle{
+		{key: "reset:email:" + strings.ToLower(strings.TrimSpace(email)), policy: passwordResetAccountPolicy},
+		{key: "reset:ip:" + clientIP(r), policy: passwordResetIPPolicy},
+	}
+}
+
+// loginLockedFor - how long until every one of the throttles allows another attempt
+func (cfg *apiConfig) loginLockedFor(ctx context.Context, throttles ...loginThrottle) (time.Duration, error) {
+	keys := make([]string, 0, len(throttles))
+	for _, throttle := range throttles {
+		keys = append(keys, throttle.key)
+	}
+
+	rows, err := cfg.db.GetLoginThrottles(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	var lockedFor time.Duration
+	for _, row := range rows {
+		if !row.LockedUntil.Valid {
+			continue
+		}
+		if remaining := time.Until(row.LockedUntil.Time); remaining > lockedFor {
+			lockedFor = remaining
+		}
+	}
+	return lockedFor, nil
+}
+
+// recordLoginFailure - counts a failed attempt against every throttle and locks the ones over their threshold
+func (cfg *apiConfig) recordLoginFailure(ctx context.Context, throttles ...loginThrottle) error {
+	for _, throttle := range throttles {
+		row, err := cfg.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+			Key:         throttle.key,
+			ResetBefore: time.Now().UTC().Add(-throttle.policy.ResetAfter),
+		})
+		if err != nil {
+			return err
+		}
+
+		lock := throttle.policy.LockDuration(int(row.Failures))
+		if lock == 0 {
+			continue
+		}
+		err = cfg.db.LockLogin(ctx, database.LockLoginParams{
+			LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(lock), Valid: true},
+			Key:         throttle.key,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func respondWithLoginLocked(w http.ResponseWriter, lockedFor time.Duration) {
+	respondWithLocked(w, lockedFor, "too many failed login attempts, try again later")
+}
+
+func respondWithLocked(w http.ResponseWriter, lockedFor time.Duration, msg string) {
+	w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(lockedFor.Seconds()))))
+	respondWithError(w, http.StatusTooManyRequests, msg, nil)
+}
@@ -8,16 +8,27 @@ import (
 	"os"
 	"time"
 
+	"github.com/imhasandl/go-restapi/internal/auth"
 	"github.com/imhasandl/go-restapi/internal/database"
+	"github.com/imhasandl/go-restapi/internal/mailer"
+	"github.com/imhasandl/go-restapi/internal/oidc"
+	"github.com/imhasandl/go-restapi/internal/reactions"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
-	db         *database.Queries
-	status     string
-	jwtSecret  string
-	webhookKey string
+	db             *database.Queries
+	dbConn         *sql.DB
+	jwtKeys        *auth.KeySet
+	webhookKey     string
+	mailer         mailer.Mailer
+	passwordHasher auth.Hasher
+	passwordPolicy auth.PasswordPolicy
+	oidcProviders  map[string]*oidc.Client
+	likes          likeService
+	reactionSet    reactions.Set
+	reactions      reactionService
 }
 
 func main() {
@@ -34,76 +45,171 @@ func main() {
 	if dbURl == "" {
 		log.Fatal("DB_URL must be set")
 	}
-	status := os.Getenv("STATUS")
-	if status == "" {
-		log.Fatal("Please set your status")
+	jwtKeys, err := loadJWTKeys()
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %s", err)
+	}
+	passwordHasher, err := loadPasswordHasher()
+	if err != nil {
+		log.Fatalf("Error loading password hasher: %s", err)
+	}
+	passwordPolicy, err := loadPasswordPolicy(passwordHasher)
+	if err != nil {
+		log.Fatalf("Error loading password policy: %s", err)
+	}
+	oidcProviders, err := loadOIDCProviders()
+	if err != nil {
+		log.Fatalf("Error loading OIDC providers: %s", err)
 	}
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable should be set")
+	reactionSet, err := loadReactions()
+	if err != nil {
+		log.Fatalf("Error loading reactions: %s", err)
 	}
 	webhookKey := os.Getenv("WEBHOOK_KEY")
 	if webhookKey == "" {
 		log.Fatal("set the webhook key")
 	}
 
+	// Mails are written to MAIL_DIR when set, otherwise they are only logged
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if mailDir := os.Getenv("MAIL_DIR"); mailDir != "" {
+		mail = mailer.FileMailer{Dir: mailDir}
+	}
+
 	dbConn, err := sql.Open("postgres", dbURl)
 	if err != nil {
 		log.Fatalf("Error opening database: %s", err)
 	}
 	dbQueries := database.New(dbConn)
 
+	reactionService := newReactionService(dbConn, dbQueries)
+
 	apiCfg := apiConfig{
-		db:         dbQueries,
-		status:     status,
-		jwtSecret:  jwtSecret,
-		webhookKey: webhookKey,
+		db:             dbQueries,
+		dbConn:         dbConn,
+		jwtKeys:        jwtKeys,
+		webhookKey:     webhookKey,
+		mailer:         mail,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		oidcProviders:  oidcProviders,
+		likes:          newLikeService(reactionService),
+		reactionSet:    reactionSet,
+		reactions:      reactionService,
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", http.FileServer(http.Dir(filepath)))
 
 	mux.HandleFunc("GET /status", apiCfg.handlerStatusCheck)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
 
 	// USERS
 	mux.HandleFunc("POST /api/users/register", apiCfg.handlerUserCreate)
 	mux.HandleFunc("POST /api/users/login", apiCfg.handlerUserLogin)
-	mux.HandleFunc("PUT /api/users/change", apiCfg.handlerUserChange)
+	mux.HandleFunc("POST /api/users/login/mfa", apiCfg.handlerLoginMFA)
+	mux.HandleFunc("PUT /api/users/change", apiCfg.middlewareAuth(apiCfg.handlerUserChange))
+	mux.HandleFunc("POST /api/users/email/verify", apiCfg.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/users/email/resend", apiCfg.middlewareAuth(apiCfg.handlerResendEmailVerification))
+	mux.HandleFunc("POST /api/users/password/forgot", apiCfg.handlerForgotPassword)
+	mux.HandleFunc("POST /api/users/password/reset", apiCfg.handlerResetPassword)
+	mux.HandleFunc("POST /api/users/mfa/totp", apiCfg.middlewareAuth(apiCfg.handlerEnrollTOTP))
+	mux.HandleFunc("POST /api/users/mfa/totp/confirm", apiCfg.middlewareAuth(apiCfg.handlerConfirmTOTP))
+	mux.HandleFunc("DELETE /api/users/mfa/totp", apiCfg.middlewareAuth(apiCfg.handlerDisableTOTP))
+	mux.HandleFunc("GET /api/users/identities", apiCfg.middlewareAuth(apiCfg.handlerListIdentities))
+	mux.HandleFunc("POST /api/users/identities/{provider}", apiCfg.middlewareAuth(apiCfg.handlerConnectIdentity))
+	mux.HandleFunc("DELETE /api/users/identities/{identity_id}", apiCfg.middlewareAuth(apiCfg.handlerDisconnectIdentity))
+
+	mux.HandleFunc("GET /api/auth/oidc/{provider}/login", apiCfg.handlerOIDCLogin)
+	mux.HandleFunc("GET /api/auth/oidc/{provider}/callback", apiCfg.handlerOIDCCallback)
 
 	mux.HandleFunc("GET /api/users", apiCfg.handlerListAllUsers)
 	mux.HandleFunc("GET /api/users/id/{user_id}", apiCfg.handlerGetUserByID)
+	mux.HandleFunc("GET /api/users/id/{user_id}/followers", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListFollowers))
+	mux.HandleFunc("GET /api/users/id/{user_id}/following", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListFollowing))
+	mux.HandleFunc("GET /api/users/id/{user_id}/likes", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListLikedPosts))
+	mux.HandleFunc("POST /api/users/id/{user_id}/follow", apiCfg.middlewareAuth(apiCfg.handlerFollowUser))
+	mux.HandleFunc("DELETE /api/users/id/{user_id}/follow", apiCfg.middlewareAuth(apiCfg.handlerUnfollowUser))
+	mux.HandleFunc("GET /api/users/follow-requests", apiCfg.middlewareAuth(apiCfg.handlerListFollowRequests))
+	mux.HandleFunc("POST /api/users/follow-requests/{user_id}/approve", apiCfg.middlewareAuth(apiCfg.handlerApproveFollowRequest))
+	mux.HandleFunc("DELETE /api/users/follow-requests/{user_id}", apiCfg.middlewareAuth(apiCfg.handlerRejectFollowRequest))
+	mux.HandleFunc("POST /api/users/id/{user_id}/block", apiCfg.middlewareAuth(apiCfg.handlerBlockUser))
+	mux.HandleFunc("DELETE /api/users/id/{user_id}/block", apiCfg.middlewareAuth(apiCfg.handlerUnblockUser))
+	mux.HandleFunc("POST /api/users/id/{user_id}/mute", apiCfg.middlewareAuth(apiCfg.handlerMuteUser))
+	mux.HandleFunc("DELETE /api/users/id/{user_id}/mute", apiCfg.middlewareAuth(apiCfg.handlerUnmuteUser))
+	mux.HandleFunc("GET /api/users/blocks", apiCfg.middlewareAuth(apiCfg.handlerListBlocks))
+	mux.HandleFunc("GET /api/users/mutes", apiCfg.middlewareAuth(apiCfg.handlerListMutes))
 	mux.HandleFunc("GET /api/users/email", apiCfg.handlerGetUserByEmail)
 	mux.HandleFunc("GET /api/users/username", apiCfg.handlerGetUserByUsername)
-	
+
 	// POSTS
-	mux.HandleFunc("POST /api/posts", apiCfg.handlerCreatePost)
-	mux.HandleFunc("GET /api/posts", apiCfg.handlerListPosts)
-	mux.HandleFunc("GET /api/posts/{post_id}", apiCfg.handlerGetPostByID)
-	mux.HandleFunc("PUT /api/posts/{post_id}", apiCfg.handlerChangePostByID)
-	mux.HandleFunc("DELETE /api/posts/{post_id}", apiCfg.handlerDeletePostByID)
-
-	mux.HandleFunc("POST /api/posts/reports", apiCfg.handlerReportPost)
-	mux.HandleFunc("GET /api/posts/reports", apiCfg.handlerListAllReports)
-	mux.HandleFunc("GET /api/posts/reports/{report_id}", apiCfg.handlerGetReportByID)
-	mux.HandleFunc("DELETE /api/posts/reports/{report_id}", apiCfg.handlerDeleteReportByID)
-
-	mux.HandleFunc("GET /api/posts/mostlikes", apiCfg.handlerGetMostLikedPost)
-	mux.HandleFunc("POST /api/posts/like/{post_id}", apiCfg.handlerLikePost)
-	mux.HandleFunc("DELETE /api/posts/dislike/{likepost_id}", apiCfg.handlerDislikePost)
-	mux.HandleFunc("GET /api/posts/likes", apiCfg.handlerListLikePost)
-	mux.HandleFunc("GET /api/posts/likes/{post_id}", apiCfg.handlerGetPostLikes)
+	mux.HandleFunc("POST /api/posts", apiCfg.middlewareAuthScope(auth.ScopePostsWrite, apiCfg.handlerCreatePost))
+	mux.HandleFunc("GET /api/posts", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListPosts))
+	mux.HandleFunc("GET /api/posts/{post_id}", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerGetPostByID))
+	mux.HandleFunc("PUT /api/posts/{post_id}", apiCfg.middlewareAuthScope(auth.ScopePostsWrite, apiCfg.handlerChangePostByID))
+	mux.HandleFunc("DELETE /api/posts/{post_id}", apiCfg.middlewareAuthScope(auth.ScopePostsWrite, apiCfg.handlerDeletePostByID))
+	mux.HandleFunc("POST /api/posts/id/{post_id}/repost", apiCfg.middlewareAuthScope(auth.ScopePostsWrite, apiCfg.handlerRepost))
+	mux.HandleFunc("DELETE /api/posts/id/{post_id}/repost", apiCfg.middlewareAuthScope(auth.ScopePostsWrite, apiCfg.handlerUndoRepost))
+	mux.HandleFunc("GET /api/posts/id/{post_id}/conversation", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerGetConversation))
+	mux.HandleFunc("GET /api/posts/id/{post_id}/revisions", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListPostRevisions))
+
+	mux.HandleFunc("POST /api/posts/reports", apiCfg.middlewareAuthScope(auth.ScopeReportsWrite, apiCfg.handlerReportPost))
+	mux.HandleFunc("GET /api/posts/reports", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerListAllReports))
+	mux.HandleFunc("GET /api/posts/reports/{report_id}", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerGetReportByID))
+	mux.HandleFunc("DELETE /api/posts/reports/{report_id}", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerDeleteReportByID))
+
+	mux.HandleFunc("GET /api/posts/mostlikes", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerGetMostLikedPost))
+	mux.HandleFunc("PUT /api/posts/id/{post_id}/like", apiCfg.middlewareAuthScope(auth.ScopeLikesWrite, apiCfg.handlerLikePost))
+	mux.HandleFunc("DELETE /api/posts/id/{post_id}/like", apiCfg.middlewareAuthScope(auth.ScopeLikesWrite, apiCfg.handlerDislikePost))
+	mux.HandleFunc("GET /api/posts/id/{post_id}/likes", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListPostLikers))
+	mux.HandleFunc("PUT /api/posts/id/{post_id}/reaction", apiCfg.middlewareAuthScope(auth.ScopeLikesWrite, apiCfg.handlerReactToPost))
+	mux.HandleFunc("DELETE /api/posts/id/{post_id}/reaction", apiCfg.middlewareAuthScope(auth.ScopeLikesWrite, apiCfg.handlerRemoveReaction))
+	mux.HandleFunc("GET /api/posts/id/{post_id}/reactions", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListPostReactions))
+	mux.HandleFunc("GET /api/reactions", apiCfg.handlerListReactionTypes)
+	// Kept for older clients, they work like the routes above
+	mux.HandleFunc("POST /api/posts/like/{post_id}", apiCfg.middlewareAuthScope(auth.ScopeLikesWrite, apiCfg.handlerLikePost))
+	mux.HandleFunc("DELETE /api/posts/dislike/{post_id}", apiCfg.middlewareAuthScope(auth.ScopeLikesWrite, apiCfg.handlerDislikePost))
+	mux.HandleFunc("GET /api/posts/likes", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerListLikePost))
+	mux.HandleFunc("GET /api/posts/likes/{post_id}", apiCfg.middlewareOptionalAuthScope(auth.ScopePostsRead, apiCfg.handlerGetPostLikes))
+
+	// TIMELINES
+	mux.HandleFunc("GET /api/timeline/home", apiCfg.middlewareAuthScope(auth.ScopePostsRead, apiCfg.handlerHomeTimeline))
+
+	// SESSIONS
+	mux.HandleFunc("GET /api/sessions", apiCfg.middlewareAuth(apiCfg.handlerListSessions))
+	mux.HandleFunc("DELETE /api/sessions", apiCfg.middlewareAuth(apiCfg.handlerRevokeAllSessions))
+	mux.HandleFunc("DELETE /api/sessions/{session_id}", apiCfg.middlewareAuth(apiCfg.handlerRevokeSession))
+
+	// API KEYS
+	mux.HandleFunc("POST /api/keys", apiCfg.middlewareAuth(apiCfg.handlerCreateAPIKey))
+	mux.HandleFunc("GET /api/keys", apiCfg.middlewareAuth(apiCfg.handlerListAPIKeys))
+	mux.HandleFunc("DELETE /api/keys/{key_id}", apiCfg.middlewareAuth(apiCfg.handlerRevokeAPIKey))
+
+	// OAUTH
+	mux.HandleFunc("POST /api/oauth/clients", apiCfg.middlewareAuth(apiCfg.handlerCreateOAuthClient))
+	mux.HandleFunc("GET /api/oauth/clients", apiCfg.middlewareAuth(apiCfg.handlerListOAuthClients))
+	mux.HandleFunc("DELETE /api/oauth/clients/{client_id}", apiCfg.middlewareAuth(apiCfg.handlerDeleteOAuthClient))
+
+	mux.HandleFunc("GET /oauth/authorize", apiCfg.handlerOAuthAuthorize)
+	mux.HandleFunc("POST /oauth/authorize", apiCfg.handlerOAuthApprove)
+	mux.HandleFunc("POST /oauth/token", apiCfg.handlerOAuthToken)
+	mux.HandleFunc("POST /oauth/introspect", apiCfg.handlerOAuthIntrospect)
+	mux.HandleFunc("POST /oauth/revoke", apiCfg.handlerOAuthRevoke)
 
 	// OTHER
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
-	
+
 	mux.HandleFunc("POST /api/webhooks", apiCfg.handlerWebhook)
-	
-	mux.HandleFunc("DELETE /admin/reset/users", apiCfg.handlerResetUsers)
-	mux.HandleFunc("DELETE /admin/reset/posts", apiCfg.handlerResetPosts)
-	mux.HandleFunc("DELETE /admin/reset/reports", apiCfg.handlerResetReports)
-	mux.HandleFunc("DELETE /admin/reset/likepost", apiCfg.handlerResetLikePost)
-	
+
+	// ADMIN
+	mux.HandleFunc("PUT /admin/users/{user_id}/role", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerSetUserRole))
+
+	mux.HandleFunc("DELETE /admin/reset/users", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerResetUsers))
+	mux.HandleFunc("DELETE /admin/reset/posts", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerResetPosts))
+	mux.HandleFunc("DELETE /admin/reset/reports", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerResetReports))
+	mux.HandleFunc("DELETE /admin/reset/likepost", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerResetLikePost))
+
 	srv := &http.Server{
 		Addr:              ":" + port,
 		Handler:           mux,
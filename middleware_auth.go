@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/auth"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+type contextKey string
+
+const (
+	contextKeyUserID    contextKey = "user_id"
+	contextKeyUser      contextKey = "user"
+	contextKeySessionID contextKey = "session_id"
+)
+
+// credentials - how a request was authenticated. Access tokens belong to a
+// session. API keys and tokens of third-party clients are scoped: they can
+// only do what their scopes allow.
+type credentials struct {
+	sessionID uuid.UUID
+	apiKeyID  uuid.UUID
+	scoped    bool
+	scopes    []auth.Scope
+}
+
+// middlewareAuth - rejects requests without a valid access token and stores
+// the authenticated user in the request context. Scoped credentials are
+// refused, the endpoint has to opt in with middlewareAuthScope.
+func (cfg *apiConfig) middlewareAuth(next http.HandlerFunc) http.HandlerFunc {
+	return cfg.withAuth(false, "", next)
+}
+
+// middlewareOptionalAuth - lets anonymous requests through, but if an
+// Authorization header is sent it has to be valid
+func (cfg *apiConfig) middlewareOptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return cfg.withAuth(true, "", next)
+}
+
+// middlewareAuthScope - like middlewareAuth, but also accepts API keys and
+// third-party tokens granted the scope
+func (cfg *apiConfig) middlewareAuthScope(scope auth.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return cfg.withAuth(false, scope, next)
+}
+
+// middlewareOptionalAuthScope - like middlewareOptionalAuth, but also
+// accepts API keys and third-party tokens granted the scope
+func (cfg *apiConfig) middlewareOptionalAuthScope(scope auth.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return cfg.withAuth(true, scope, next)
+}
+
+func (cfg *apiConfig) withAuth(optional bool, scope auth.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if optional && r.Header.Get("Authorization") == "" {
+			next(w, r)
+			return
+		}
+
+		user, creds, err := cfg.authenticate(r)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "can't authenticate user", err)
+			return
+		}
+
+		if creds.scoped {
+			if scope == "" {
+				respondWithError(w, http.StatusForbidden, "api keys and app tokens can't be used for this endpoint", nil)
+				return
+			}
+			if !auth.HasScope(creds.scopes, scope) {
+				respondWithError(w, http.StatusForbidden, fmt.Sprintf("credentials are missing the %s scope", scope), nil)
+				return
+			}
+		}
+
+		next(w, r.WithContext(contextWithUser(r.Context(), user, creds)))
+	}
+}
+
+func (cfg *apiConfig) authenticate(r *http.Request) (database.User, credentials, error) {
+	if key, err := auth.GetAPIKey(r.Header); err == nil {
+		return cfg.authenticateAPIKey(r.Context(), key)
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return database.User{}, credentials{}, err
+	}
+
+	claims, err := auth.ValidateJWT(token, cfg.jwtKeys, cfg.sessionChecker(r.Context()))
+	if err != nil {
+		return database.User{}, credentials{}, err
+	}
+
+	user, err := cfg.getAuthenticatedUser(r.Context(), claims.UserID)
+	if err != nil {
+		return database.User{}, credentials{}, err
+	}
+
+	// Like api keys, only written about once a minute
+	if claims.SessionID != uuid.Nil {
+		err = cfg.db.TouchSession(r.Context(), claims.SessionID)
+		if err != nil {
+			log.Printf("Can't update last use of session %s: %s", claims.SessionID, err)
+		}
+	}
+
+	return user, credentials{
+		sessionID: claims.SessionID,
+		scoped:    claims.ClientID != "",
+		scopes:    claims.Scopes,
+	}, nil
+}
+
+func (cfg *apiConfig) authenticateAPIKey(ctx context.Context, key string) (database.User, credentials, error) {
+	apiKey, err := cfg.db.GetActiveAPIKeyByHash(ctx, auth.HashToken(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, credentials{}, errors.New("invalid api key")
+		}
+		return database.User{}, credentials{}, err
+	}
+
+	user, err := cfg.getAuthenticatedUser(ctx, apiKey.UserID)
+	if err != nil {
+		return database.User{}, credentials{}, err
+	}
+
+	// Only written about once a minute, so busy bots don't turn every request into a write
+	err = cfg.db.TouchAPIKey(ctx, apiKey.ID)
+	if err != nil {
+		log.Printf("Can't update last use of api key %s: %s", apiKey.ID, err)
+	}
+
+	return user, credentials{apiKeyID: apiKey.ID, scoped: true, scopes: scopesFromNames(apiKey.Scopes)}, nil
+}
+
+func (cfg *apiConfig) getAuthenticatedUser(ctx context.Context, userID uuid.UUID) (database.User, error) {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, errors.New("user from credentials no longer exists")
+		}
+		return database.User{}, err
+	}
+	return user, nil
+}
+
+func contextWithUser(ctx context.Context, user database.User, creds credentials) context.Context {
+	ctx = context.WithValue(ctx, contextKeyUserID, user.ID)
+	if creds.sessionID != uuid.Nil {
+		ctx = context.WithValue(ctx, contextKeySessionID, creds.sessionID)
+	}
+	return context.WithValue(ctx, contextKeyUser, user)
+}
+
+// userIDFromContext - returns the ID of the authenticated user, ok is false
+// for anonymous requests
+func userIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(contextKeyUserID).(uuid.UUID)
+	return userID, ok
+}
+
+// userFromContext - returns the authenticated user loaded by the middleware
+func userFromContext(ctx context.Context) (database.User, bool) {
+	user, ok := ctx.Value(contextKeyUser).(database.User)
+	return user, ok
+}
+
+// sessionIDFromContext - returns the session the access token was issued for
+func sessionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	sessionID, ok := ctx.Value(contextKeySessionID).(uuid.UUID)
+	return sessionID, ok
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/imhasandl/go-restapi/internal/auth"
+)
+
+// middlewareRequireRole - only lets the request through when the
+// authenticated user has at least the required role
+func (cfg *apiConfig) middlewareRequireRole(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := userFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "can't authenticate user", nil)
+			return
+		}
+
+		if !auth.Role(user.Role).Allows(role) {
+			respondWithError(w, http.StatusForbidden, "you don't have permission to do this", nil)
+			return
+		}
+
+		next(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/imhasandl/go-restapi/internal/auth"
+)
+
+var scopeDescriptions = map[auth.Scope]string{
+	auth.ScopePostsRead:    "Read posts",
+	auth.ScopePostsWrite:   "Create and delete posts as you",
+	auth.ScopeLikesWrite:   "Like and unlike posts as you",
+	auth.ScopeReportsWrite: "Report posts as you",
+}
+
+var oauthTemplates = template.Must(template.New("oauth").Parse(`
+{{define "consent"}}<!DOCTYPE html>
+<html>
+<head>
+   <meta charset="utf-8">
+   <title>Authorize {{.ClientName}}</title>
+</head>
+<body>
+   <h1>{{.ClientName}} wants to access your account</h1>
+   <p>It will be able to:</p>
+   <ul>
+      {{range .Scopes}}<li>{{.}}</li>
+      {{end}}
+   </ul>
+   {{if .Error}}<p role="alert"><strong>{{.Error}}</strong></p>{{end}}
+   <form method="post" action="/oauth/authorize">
+      <input type="hidden" name="response_type" value="code">
+      <input type="hidden" name="client_id" value="{{.ClientID}}">
+      <input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+      <input type="hidden" name="scope" value="{{.Scope}}">
+      <input type="hidden" name="state" value="{{.State}}">
+      <input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+      <input type="hidden" name="code_challenge_method" value="S256">
+      <p><label>Email <input type="email" name="email" value="{{.Email}}" autocomplete="username"></label></p>
+      <p><label>Password <input type="password" name="password" autocomplete="current-password"></label></p>
+      <p><label>Authenticator code, if enabled <input name="code" inputmode="numeric" autocomplete="one-time-code"></label></p>
+      <button type="submit" name="decision" value="allow">Allow</button>
+      <button type="submit" name="decision" value="deny">Deny</button>
+   </form>
+</body>
+</html>
+{{end}}
+{{define "error"}}<!DOCTYPE html>
+<html>
+<head>
+   <meta charset="utf-8">
+   <title>Authorization failed</title>
+</head>
+<body>
+   <h1>Authorization failed</h1>
+   <p>{{.}}</p>
+</body>
+</html>
+{{end}}
+`))
+
+type consentPage struct {
+	ClientName    string
+	ClientID      string
+	RedirectURI   string
+	Scope         string
+	Scopes        []string
+	State         string
+	CodeChallenge string
+	Email         string
+	Error         string
+}
+
+func newConsentPage(req authorizeRequest) consentPage {
+	scopes := make([]string, 0, len(req.scopes))
+	for _, scope := range req.scopes {
+		scopes = append(scopes, scopeDescriptions[scope])
+	}
+
+	return consentPage{
+		ClientName:    req.client.Name,
+		ClientID:      req.client.ID,
+		RedirectURI:   req.redirectURI,
+		Scope:         auth.FormatScopes(req.scopes),
+		Scopes:        scopes,
+		State:         req.state,
+		CodeChallenge: req.codeChallenge,
+	}
+}
+
+// renderOAuthPage - the consent page asks for the password, so it must never
+// be framed by another site or cached. form-action is left out of the CSP,
+// browsers would apply it to the redirect back to the client.
+func renderOAuthPage(w http.ResponseWriter, code int, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(code)
+
+	err := oauthTemplates.ExecuteTemplate(w, name, data)
+	if err != nil {
+		log.Printf("Error rendering oauth page %s: %s", name, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/imhasandl/go-restapi/internal/auth"
+)
+
+// loadPasswordHasher - PASSWORD_HASHER picks bcrypt (default) or argon2id,
+// their parameters come from BCRYPT_COST and ARGON2_* variables
+func loadPasswordHasher() (auth.Hasher, error) {
+	switch os.Getenv("PASSWORD_HASHER") {
+	case "", "bcrypt":
+		cost, err := envInt("BCRYPT_COST", 14)
+		if err != nil {
+			return nil, err
+		}
+		return auth.BcryptHasher{Cost: cost}, nil
+	case "argon2id":
+		memory, err := envInt("ARGON2_MEMORY_KIB", 64*1024)
+		if err != nil {
+			return nil, err
+		}
+		iterations, err := envInt("ARGON2_ITERATIONS", 3)
+		if err != nil {
+			return nil, err
+		}
+		parallelism, err := envInt("ARGON2_PARALLELISM", 2)
+		if err != nil {
+			return nil, err
+		}
+		if memory <= 0 || iterations <= 0 || parallelism <= 0 || parallelism > 255 {
+			return nil, fmt.Errorf("argon2 parameters out of range")
+		}
+		return auth.Argon2idHasher{
+			Memory:      uint32(memory),     // #nosec G115 -- checked above
+			Iterations:  uint32(iterations), // #nosec G115 -- checked above
+			Parallelism: uint8(parallelism), // #nosec G115 -- checked above
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_HASHER %q", os.Getenv("PASSWORD_HASHER"))
+	}
+}
+
+// loadPasswordPolicy - PASSWORD_MIN_LENGTH and an optional COMMON_PASSWORDS_FILE
+// with one forbidden password per line, the maximum length comes from hasher
+func loadPasswordPolicy(hasher auth.Hasher) (auth.PasswordPolicy, error) {
+	policy := auth.DefaultPasswordPolicy.PolicyFor(hasher)
+
+	minLength, err := envInt("PASSWORD_MIN_LENGTH", policy.MinLength)
+	if err != nil {
+		return auth.PasswordPolicy{}, err
+	}
+	policy.MinLength = minLength
+
+	commonFile := os.Getenv("COMMON_PASSWORDS_FILE")
+	if commonFile == "" {
+		return policy, nil
+	}
+	file, err := os.Open(filepath.Clean(commonFile))
+	if err != nil {
+		return auth.PasswordPolicy{}, fmt.Errorf("can't open common passwords file: %w", err)
+	}
+	defer file.Close()
+
+	policy.Common, err = auth.LoadCommonPasswords(file)
+	if err != nil {
+		return auth.PasswordPolicy{}, fmt.Errorf("can't read common passwords file: %w", err)
+	}
+	return policy, nil
+}
+
+func envInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", name, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+// reactionService - sets and removes reactions on posts. A reaction and the
+// counts of its post, likes included, always change in one transaction with
+// the post row locked, so concurrent requests can't skew the counts.
+type reactionService struct {
+	dbConn *sql.DB
+	db     *database.Queries
+}
+
+func newReactionService(dbConn *sql.DB, db *database.Queries) reactionService {
+	return reactionService{dbConn: dbConn, db: db}
+}
+
+// React - sets the reaction of userID on postID, replacing the one they left
+// before. previous is the name of that reaction, empty when they hadn't
+// reacted. A missing post is sql.ErrNoRows.
+func (s reactionService) React(ctx context.Context, postID, userID uuid.UUID, name string) (reaction database.PostReaction, previous string, err error) {
+	err = runInTx(ctx, s.dbConn, s.db, func(qtx *database.Queries) error {
+		_, err := qtx.GetPostByIDForUpdate(ctx, postID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := qtx.GetReaction(ctx, database.GetReactionParams{
+			PostID: postID,
+			UserID: userID,
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		previous = existing.Reaction
+		if previous == name {
+			reaction = existing
+			return nil
+		}
+
+		reaction, err = qtx.SetReaction(ctx, database.SetReactionParams{
+			PostID:   postID,
+			UserID:   userID,
+			Reaction: name,
+		})
+		if err != nil {
+			return err
+		}
+
+		if previous != "" {
+			err = qtx.AddPostReactionCount(ctx, database.AddPostReactionCountParams{
+				Reaction: previous,
+				Delta:    -1,
+				PostID:   postID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return qtx.AddPostReactionCount(ctx, database.AddPostReactionCountParams{
+			Reaction: name,
+			Delta:    1,
+			PostID:   postID,
+		})
+	})
+	return reaction, previous, err
+}
+
+// Unreact - removes the reaction of userID from postID, only if it is named
+// only when that isn't empty. Without such a reaction, or a post, it does
+// nothing and returns removed false.
+func (s reactionService) Unreact(ctx context.Context, postID, userID uuid.UUID, only string) (removed bool, err error) {
+	err = runInTx(ctx, s.dbConn, s.db, func(qtx *database.Queries) error {
+		_, err := qtx.GetPostByIDForUpdate(ctx, postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		reaction, err := qtx.DeleteReaction(ctx, database.DeleteReactionParams{
+			PostID:   postID,
+			UserID:   userID,
+			Reaction: sql.NullString{String: only, Valid: only != ""},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		removed = true
+		return qtx.AddPostReactionCount(ctx, database.AddPostReactionCountParams{
+			Reaction: reaction.Reaction,
+			Delta:    -1,
+			PostID:   postID,
+		})
+	})
+	return removed, err
+}
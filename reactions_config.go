@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+
+	"github.com/imhasandl/go-restapi/internal/reactions"
+)
+
+// loadReactions - REACTIONS lists the reactions users can leave as
+// comma separated name=emoji pairs, see reactions.DefaultReactions
+func loadReactions() (reactions.Set, error) {
+	value := os.Getenv("REACTIONS")
+	if value == "" {
+		value = reactions.DefaultReactions
+	}
+	return reactions.ParseSet(value)
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net"
+	"net/http"
+)
+
+// clientIP - address of the client that opened the connection. Headers like
+// X-Forwarded-For are ignored because any client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
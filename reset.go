@@ -6,15 +6,6 @@ import (
 )
 
 func (cfg *apiConfig) handlerResetUsers(w http.ResponseWriter, r *http.Request) {
-	if cfg.status != "ADMIN" {
-		w.WriteHeader(http.StatusForbidden)
-		_, err := w.Write([]byte("Reset users only allowed in admin environment"))
-		if err != nil {
-			log.Printf("Error writing JSON: %s", err)
-		}
-		return
-	}
-	
 	err := cfg.db.ResetUsers(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't reset users", err)
@@ -29,15 +20,6 @@ func (cfg *apiConfig) handlerResetUsers(w http.ResponseWriter, r *http.Request)
 }
 
 func (cfg *apiConfig) handlerResetPosts(w http.ResponseWriter, r *http.Request) {
-	if cfg.status != "ADMIN" {
-		w.WriteHeader(http.StatusForbidden)
-		_, err := w.Write([]byte("Reset posts only allowed in admin environment"))
-		if err != nil {
-			log.Printf("Error writing JSON: %s", err)
-		}
-		return
-	}
-
 	err := cfg.db.ResetPosts(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't reset posts", err)
@@ -52,15 +34,6 @@ func (cfg *apiConfig) handlerResetPosts(w http.ResponseWriter, r *http.Request)
 }
 
 func (cfg *apiConfig) handlerResetReports(w http.ResponseWriter, r *http.Request) {
-	if cfg.status != "ADMIN" {
-		w.WriteHeader(http.StatusForbidden)
-		_, err := w.Write([]byte("Reset posts only allowed in admin environment"))
-		if err != nil {
-			log.Printf("Error writing JSON: %s", err)
-		}
-		return
-	}
-
 	err := cfg.db.ResetReports(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't reset posts", err)
@@ -75,15 +48,6 @@ func (cfg *apiConfig) handlerResetReports(w http.ResponseWriter, r *http.Request
 }
 
 func (cfg *apiConfig) handlerResetLikePost(w http.ResponseWriter, r *http.Request) {
-	if cfg.status != "ADMIN" {
-		w.WriteHeader(http.StatusForbidden)
-		_, err := w.Write([]byte("Reset like's of post only allowed in admin environment"))
-		if err != nil {
-			log.Printf("Error writing JSON: %s", err)
-		}
-		return
-	}
-
 	err := cfg.db.ResetLikePost(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "can't reset like's of post", err)
@@ -95,4 +59,4 @@ func (cfg *apiConfig) handlerResetLikePost(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		log.Printf("Error writing JSON: %s", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,68 @@
+-- name: UsersBlocked :one
+SELECT users_blocked(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid)::boolean AS blocked;
+
+-- name: CreateBlock :one
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING *;
+
+-- name: DeleteBlock :one
+DELETE FROM blocks
+WHERE blocker_id = $1
+AND blocked_id = $2
+RETURNING *;
+
+-- name: ListBlocksDesc :many
+SELECT users.id, users.username, blocks.created_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (blocks.created_at, blocks.blocked_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListBlocksAsc :many
+SELECT users.id, users.username, blocks.created_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (blocks.created_at, blocks.blocked_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY blocks.created_at ASC, blocks.blocked_id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateMute :one
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING *;
+
+-- name: DeleteMute :one
+DELETE FROM mutes
+WHERE muter_id = $1
+AND muted_id = $2
+RETURNING *;
+
+-- name: ListMutesDesc :many
+SELECT users.id, users.username, mutes.created_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (mutes.created_at, mutes.muted_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListMutesAsc :many
+SELECT users.id, users.username, mutes.created_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (mutes.created_at, mutes.muted_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY mutes.created_at ASC, mutes.muted_id ASC
+LIMIT sqlc.arg('limit');
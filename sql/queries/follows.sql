@@ -0,0 +1,112 @@
+-- name: CreateFollow :one
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING *;
+
+-- name: DeleteFollow :one
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2
+RETURNING *;
+
+-- name: DeleteFollowsBetween :many
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_id') AND followee_id = sqlc.arg('other_id'))
+OR (follower_id = sqlc.arg('other_id') AND followee_id = sqlc.arg('user_id'))
+RETURNING *;
+
+-- name: UpdateFollowCounts :exec
+UPDATE users SET
+follower_count = follower_count + CASE WHEN id = sqlc.arg('followee_id')::uuid THEN sqlc.arg('delta')::int ELSE 0 END,
+following_count = following_count + CASE WHEN id = sqlc.arg('follower_id')::uuid THEN sqlc.arg('delta')::int ELSE 0 END
+WHERE id IN (sqlc.arg('follower_id')::uuid, sqlc.arg('followee_id')::uuid);
+
+-- name: ListFollowersDesc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')
+AND NOT users_blocked(users.id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (follows.created_at, follows.follower_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowersAsc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')
+AND NOT users_blocked(users.id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (follows.created_at, follows.follower_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at ASC, follows.follower_id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowingDesc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND NOT users_blocked(users.id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (follows.created_at, follows.followee_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowingAsc :many
+SELECT users.id, users.username, follows.created_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND NOT users_blocked(users.id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (follows.created_at, follows.followee_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at ASC, follows.followee_id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollow :one
+SELECT * FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: AuthorVisible :one
+SELECT author_visible(sqlc.arg('author_id')::uuid, sqlc.narg('viewer_id')::uuid)::boolean AS visible;
+
+-- name: CreateFollowRequest :one
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES (
+   $1,
+   $2,
+   NOW()
+)
+RETURNING *;
+
+-- name: DeleteFollowRequest :one
+DELETE FROM follow_requests
+WHERE requester_id = $1
+AND target_id = $2
+RETURNING *;
+
+-- name: DeleteFollowRequestsBetween :exec
+DELETE FROM follow_requests
+WHERE (requester_id = sqlc.arg('user_id') AND target_id = sqlc.arg('other_id'))
+OR (requester_id = sqlc.arg('other_id') AND target_id = sqlc.arg('user_id'));
+
+-- name: ListFollowRequestsDesc :many
+SELECT users.id, users.username, follow_requests.created_at FROM follow_requests
+JOIN users ON users.id = follow_requests.requester_id
+WHERE follow_requests.target_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (follow_requests.created_at, follow_requests.requester_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follow_requests.created_at DESC, follow_requests.requester_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowRequestsAsc :many
+SELECT users.id, users.username, follow_requests.created_at FROM follow_requests
+JOIN users ON users.id = follow_requests.requester_id
+WHERE follow_requests.target_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (follow_requests.created_at, follow_requests.requester_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follow_requests.created_at ASC, follow_requests.requester_id ASC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,26 @@
+-- name: GetLoginThrottles :many
+SELECT * FROM login_throttles
+WHERE key = ANY(sqlc.arg(keys)::TEXT[]);
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (
+   sqlc.arg(key),
+   1,
+   NOW()
+)
+ON CONFLICT (key) DO UPDATE SET
+failures = CASE
+   WHEN login_throttles.last_failure_at < sqlc.arg(reset_before) THEN 1
+   ELSE login_throttles.failures + 1
+END,
+last_failure_at = NOW()
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_throttles SET locked_until = $1
+WHERE key = $2;
+
+-- name: ResetLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
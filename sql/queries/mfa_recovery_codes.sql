@@ -0,0 +1,18 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (id, created_at, user_id, code_hash)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3
+);
+
+-- name: DeleteRecoveryCodesByUser :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes SET used_at = NOW()
+WHERE user_id = $1
+AND code_hash = $2
+AND used_at IS NULL;
@@ -0,0 +1,32 @@
+-- name: CreateAuthorizationCode :one
+INSERT INTO oauth_authorization_codes (code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5,
+   $6,
+   $7
+)
+RETURNING *;
+
+-- name: ConsumeAuthorizationCode :one
+UPDATE oauth_authorization_codes SET used_at = NOW()
+WHERE code_hash = $1
+AND used_at IS NULL
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: GetAuthorizationCode :one
+SELECT * FROM oauth_authorization_codes
+WHERE code_hash = $1;
+
+-- name: SetAuthorizationCodeSession :exec
+UPDATE oauth_authorization_codes SET session_id = $2
+WHERE code_hash = $1;
+
+-- name: DeleteExpiredAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes
+WHERE expires_at <= NOW() - INTERVAL '1 day';
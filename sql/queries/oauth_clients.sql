@@ -0,0 +1,27 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, created_at, updated_at, user_id, name, redirect_uris, secret_hash)
+VALUES (
+   $1,
+   NOW(),
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5
+)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = $1;
+
+-- name: ListOAuthClientsByUser :many
+SELECT * FROM oauth_clients
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteOAuthClient :one
+DELETE FROM oauth_clients
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
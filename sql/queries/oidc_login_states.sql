@@ -0,0 +1,23 @@
+-- name: CreateOIDCLoginState :one
+INSERT INTO oidc_login_states (state_hash, created_at, provider, nonce, code_verifier, user_id, expires_at, binding_hash)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5,
+   $6,
+   $7
+)
+RETURNING *;
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= NOW();
@@ -0,0 +1,57 @@
+-- name: GetReaction :one
+SELECT * FROM post_reactions
+WHERE post_id = $1 AND user_id = $2;
+
+-- name: SetReaction :one
+INSERT INTO post_reactions (post_id, user_id, reaction, created_at)
+VALUES (
+   $1,
+   $2,
+   $3,
+   NOW()
+)
+ON CONFLICT (post_id, user_id) DO UPDATE SET
+reaction = EXCLUDED.reaction, created_at = EXCLUDED.created_at
+RETURNING *;
+
+-- name: DeleteReaction :one
+DELETE FROM post_reactions
+WHERE post_id = sqlc.arg('post_id') AND user_id = sqlc.arg('user_id')
+AND (sqlc.narg('reaction')::text IS NULL OR reaction = sqlc.narg('reaction')::text)
+RETURNING *;
+
+-- name: AddPostReactionCount :exec
+-- likes - the count of the like reaction, kept as its own column for sorting
+UPDATE posts SET likes = likes + CASE WHEN sqlc.arg('reaction')::text = 'like' THEN sqlc.arg('delta')::int ELSE 0 END,
+reaction_counts = CASE
+   WHEN COALESCE((reaction_counts->>sqlc.arg('reaction')::text)::int, 0) + sqlc.arg('delta')::int <= 0
+      THEN reaction_counts - sqlc.arg('reaction')::text
+   ELSE jsonb_set(
+      reaction_counts,
+      ARRAY[sqlc.arg('reaction')::text],
+      to_jsonb(COALESCE((reaction_counts->>sqlc.arg('reaction')::text)::int, 0) + sqlc.arg('delta')::int)
+   )
+END
+WHERE id = sqlc.arg('post_id');
+
+-- name: ListReactionsDesc :many
+SELECT users.id, users.username, post_reactions.reaction, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = sqlc.arg('post_id')
+AND author_visible(post_reactions.user_id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('reaction')::text IS NULL OR post_reactions.reaction = sqlc.narg('reaction')::text)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY post_reactions.created_at DESC, post_reactions.user_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListReactionsAsc :many
+SELECT users.id, users.username, post_reactions.reaction, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = sqlc.arg('post_id')
+AND author_visible(post_reactions.user_id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('reaction')::text IS NULL OR post_reactions.reaction = sqlc.narg('reaction')::text)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY post_reactions.created_at ASC, post_reactions.user_id ASC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,19 @@
+-- name: CreatePostRevision :one
+INSERT INTO post_revisions (id, created_at, post_id, edited_by, body)
+VALUES (
+   $1,
+   NOW(),
+   $2,
+   $3,
+   $4
+)
+RETURNING *;
+
+-- name: ListPostRevisions :many
+SELECT * FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeletePostRevisions :exec
+DELETE FROM post_revisions
+WHERE post_id = $1;
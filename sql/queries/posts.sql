@@ -1,30 +1,158 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, user_id, body, likes)
+INSERT INTO posts (id, created_at, updated_at, user_id, body, likes, parent_id, root_id, repost_of_id, quote_of_id, visibility)
 VALUES (
    $1,
    NOW(),
    NOW(),
    $2,
    $3,
-   $4
+   $4,
+   $5,
+   $6,
+   $7,
+   $8,
+   $9
 )
-RETURNING id, created_at, updated_at, user_id, body, likes;
+RETURNING *;
 
--- name: GetPosts :many
-SELECT * FROM posts;
+-- name: ListPostsDesc :many
+SELECT * FROM posts
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.narg('viewer_id')::uuid)
+-- Unlisted posts and muted accounts are only shown when the author's posts are asked for
+AND (sqlc.narg('author_id')::uuid IS NOT NULL OR visibility <> 'unlisted')
+AND (sqlc.narg('author_id')::uuid IS NOT NULL OR NOT EXISTS (
+   SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id')::uuid AND muted_id = posts.user_id
+))
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsAsc :many
+SELECT * FROM posts
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.narg('viewer_id')::uuid)
+-- Unlisted posts and muted accounts are only shown when the author's posts are asked for
+AND (sqlc.narg('author_id')::uuid IS NOT NULL OR visibility <> 'unlisted')
+AND (sqlc.narg('author_id')::uuid IS NOT NULL OR NOT EXISTS (
+   SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id')::uuid AND muted_id = posts.user_id
+))
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: GetPostByID :one
 SELECT * FROM posts
 WHERE id = $1;
 
--- name: ChangePostByID :exec
+-- name: GetPostsByIDs :many
+SELECT * FROM posts
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetPostByIDForUpdate :one
+SELECT * FROM posts
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ChangePostByID :one
+UPDATE posts SET
+body = $1, updated_at = NOW(), edited_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SetPostVisibility :one
 UPDATE posts SET
-body = $1, updated_at = NOW()
-WHERE id = $2;
+visibility = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: PostVisible :one
+SELECT post_visible(sqlc.arg('post_id')::uuid, sqlc.narg('viewer_id')::uuid)::boolean AS visible;
+
+-- name: CreatePostMentions :exec
+INSERT INTO post_mentions (post_id, user_id)
+SELECT sqlc.arg('post_id')::uuid, users.id FROM users
+WHERE users.username = ANY(sqlc.arg('usernames')::text[])
+AND NOT users_blocked(users.id, (SELECT posts.user_id FROM posts WHERE posts.id = sqlc.arg('post_id')::uuid))
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostMentions :exec
+DELETE FROM post_mentions
+WHERE post_id = $1;
 
 -- name: DeletePostByID :exec
 DELETE FROM posts WHERE id = $1;
 
 -- name: GetMostLikedPosts :many
 SELECT * FROM posts
-ORDER BY likes ASC LIMIT 10;
\ No newline at end of file
+WHERE deleted_at IS NULL
+AND visibility = 'public'
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY likes ASC LIMIT 10;
+
+-- name: TombstonePost :exec
+UPDATE posts SET
+body = '', quote_of_id = NULL, repost_count = 0, updated_at = NOW(), deleted_at = NOW()
+WHERE id = $1;
+
+-- name: IncrementPostReplyCount :exec
+UPDATE posts SET reply_count = reply_count + 1
+WHERE id = $1;
+
+-- name: DecrementPostReplyCount :exec
+UPDATE posts SET reply_count = reply_count - 1
+WHERE id = $1;
+
+-- name: ListRepliesDesc :many
+SELECT * FROM posts
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND parent_id = sqlc.arg('parent_id')
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListRepliesAsc :many
+SELECT * FROM posts
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND parent_id = sqlc.arg('parent_id')
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListRepliesByParents :many
+SELECT * FROM posts
+WHERE parent_id = ANY(sqlc.arg('parent_ids')::uuid[])
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetRepost :one
+SELECT * FROM posts
+WHERE user_id = $1
+AND repost_of_id = $2;
+
+-- name: DeleteRepostsOf :exec
+DELETE FROM posts
+WHERE repost_of_id = $1;
+
+-- name: IncrementPostRepostCount :exec
+UPDATE posts SET repost_count = repost_count + 1
+WHERE id = $1;
+
+-- name: DecrementPostRepostCount :exec
+UPDATE posts SET repost_count = repost_count - 1
+WHERE id = $1;
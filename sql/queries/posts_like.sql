@@ -1,32 +1,95 @@
--- name: LikePost :one
-INSERT INTO posts_likes (id, post_id, user_id, created_at)
-VALUES (
-   $1,
-   $2,
-   $3,
-   NOW()
-)
-RETURNING *;
-
--- name: DislikePost :exec
-DELETE FROM posts_likes 
-WHERE user_id = $1 AND post_id = $2;
-
--- name: ListLikePost :many
-SELECT * FROM posts_likes;
-
--- name: CheckIfUserLikeAlready :exec
-SELECT id FROM posts_likes
-WHERE user_id = $1;
-
--- name: IncrementPostLike :exec
-UPDATE posts SET likes = likes + 1
-WHERE id = $1;
-
--- name: DecrementPostLike :exec
-UPDATE posts SET likes = likes - 1
-WHERE id = $1;
+-- name: ListLikesDesc :many
+SELECT id, post_id, user_id, created_at FROM post_reactions
+WHERE reaction = 'like'
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND (sqlc.narg('post_id')::uuid IS NULL OR post_id = sqlc.narg('post_id')::uuid)
+AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id')::uuid)
+AND post_visible(post_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListLikesAsc :many
+SELECT id, post_id, user_id, created_at FROM post_reactions
+WHERE reaction = 'like'
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND (sqlc.narg('post_id')::uuid IS NULL OR post_id = sqlc.narg('post_id')::uuid)
+AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id')::uuid)
+AND post_visible(post_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: GetPostLikes :one
 SELECT likes from posts
-WHERE id = $1; 
\ No newline at end of file
+WHERE id = $1; 
+
+-- name: RecountPostReactions :execrows
+UPDATE posts SET reaction_counts = counted.reaction_counts,
+likes = COALESCE((counted.reaction_counts->>'like')::int, 0)
+FROM (
+   SELECT posts.id, COALESCE((
+      SELECT jsonb_object_agg(per_reaction.reaction, per_reaction.count) FROM (
+         SELECT reaction, COUNT(*) AS count FROM post_reactions
+         WHERE post_reactions.post_id = posts.id
+         GROUP BY reaction
+      ) AS per_reaction
+   ), '{}')::jsonb AS reaction_counts FROM posts
+) AS counted
+WHERE posts.id = counted.id
+AND (posts.reaction_counts <> counted.reaction_counts
+   OR posts.likes <> COALESCE((counted.reaction_counts->>'like')::int, 0));
+
+-- name: ListLikedPostIDs :many
+SELECT post_id FROM post_reactions
+WHERE user_id = sqlc.arg('user_id')
+AND reaction = 'like'
+AND post_id = ANY(sqlc.arg('post_ids')::uuid[]);
+
+-- name: ListPostLikersDesc :many
+SELECT users.id, users.username, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = sqlc.arg('post_id')
+AND post_reactions.reaction = 'like'
+AND author_visible(post_reactions.user_id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY post_reactions.created_at DESC, post_reactions.user_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostLikersAsc :many
+SELECT users.id, users.username, post_reactions.created_at FROM post_reactions
+JOIN users ON users.id = post_reactions.user_id
+WHERE post_reactions.post_id = sqlc.arg('post_id')
+AND post_reactions.reaction = 'like'
+AND author_visible(post_reactions.user_id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.user_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY post_reactions.created_at ASC, post_reactions.user_id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListLikedPostsDesc :many
+SELECT sqlc.embed(posts), post_reactions.created_at AS liked_at FROM post_reactions
+JOIN posts ON posts.id = post_reactions.post_id
+WHERE post_reactions.user_id = sqlc.arg('user_id')
+AND post_reactions.reaction = 'like'
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.post_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY post_reactions.created_at DESC, post_reactions.post_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListLikedPostsAsc :many
+SELECT sqlc.embed(posts), post_reactions.created_at AS liked_at FROM post_reactions
+JOIN posts ON posts.id = post_reactions.post_id
+WHERE post_reactions.user_id = sqlc.arg('user_id')
+AND post_reactions.reaction = 'like'
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, sqlc.narg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.narg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (post_reactions.created_at, post_reactions.post_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY post_reactions.created_at ASC, post_reactions.post_id ASC
+LIMIT sqlc.arg('limit');
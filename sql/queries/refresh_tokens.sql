@@ -1,23 +1,47 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, family_id, client_id, scopes)
 VALUES (
    $1,
    NOW(),
    NOW(),
    $2,
-   $3
+   $3,
+   $4,
+   $5,
+   $6
 )
 RETURNING *;
 
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens
+WHERE token = $1;
+
 -- name: RevokeRefreshToken :one
 UPDATE refresh_tokens SET revoked_at = NOW(),
-updated_at = NOW()
+updated_at = NOW(), revoked_reason = 'logout'
 WHERE token = $1
 RETURNING *;
 
--- name: GetUserFromRefreshToken :one
-SELECT users.* FROM users
-JOIN refresh_tokens ON users.id = refresh_tokens.user_id
-WHERE refresh_tokens.token = $1
+-- name: RotateRefreshToken :one
+UPDATE refresh_tokens SET revoked_at = NOW(),
+updated_at = NOW(), replaced_by = $2, revoked_reason = 'rotated'
+WHERE token = $1
 AND revoked_at IS NULL
-AND expires_at > NOW(); 
+RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens SET revoked_at = COALESCE(revoked_at, NOW()),
+updated_at = NOW(), reuse_detected_at = NOW(), revoked_reason = COALESCE(revoked_reason, 'reuse')
+WHERE family_id = $1;
+
+-- name: RevokeRefreshTokensByFamily :exec
+UPDATE refresh_tokens SET revoked_at = NOW(),
+updated_at = NOW(), revoked_reason = 'logout'
+WHERE family_id = $1
+AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokensByUser :exec
+UPDATE refresh_tokens SET revoked_at = NOW(),
+updated_at = NOW(), revoked_reason = 'logout'
+WHERE user_id = $1
+AND revoked_at IS NULL;
@@ -14,8 +14,21 @@ RETURNING *;
 SELECT * FROM reports 
 WHERE report_id = $1;
 
--- name: ListAllReports :many
-SELECT * FROM reports;
+-- name: ListReportsDesc :many
+SELECT * FROM reports
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, report_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND (sqlc.narg('post_id')::uuid IS NULL OR post_id = sqlc.narg('post_id')::uuid)
+ORDER BY created_at DESC, report_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListReportsAsc :many
+SELECT * FROM reports
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, report_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND (sqlc.narg('post_id')::uuid IS NULL OR post_id = sqlc.narg('post_id')::uuid)
+ORDER BY created_at ASC, report_id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: DeleteReportByID :exec
 DELETE FROM reports
@@ -8,4 +8,5 @@ DELETE FROM posts;
 DELETE FROM reports;
 
 -- name: ResetLikePost :exec
-DELETE FROM posts_likes;
\ No newline at end of file
+DELETE FROM post_reactions
+WHERE reaction = 'like';
@@ -0,0 +1,46 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, created_at, updated_at, user_id, user_agent, ip_address, device_name, last_used_at, client_id)
+VALUES (
+   $1,
+   NOW(),
+   NOW(),
+   $2,
+   $3,
+   $4,
+   $5,
+   NOW(),
+   $6
+)
+RETURNING *;
+
+-- name: GetSessionByID :one
+SELECT * FROM sessions
+WHERE id = $1;
+
+-- name: ListActiveSessionsByUser :many
+SELECT * FROM sessions
+WHERE user_id = $1
+AND revoked_at IS NULL
+ORDER BY last_used_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions SET last_used_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND last_used_at < NOW() - INTERVAL '1 minute';
+
+-- name: RevokeSession :one
+UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeAllSessionsForUser :exec
+UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+AND revoked_at IS NULL;
+
+-- name: FlagSessionReuse :exec
+UPDATE sessions SET revoked_at = COALESCE(revoked_at, NOW()),
+updated_at = NOW(), reuse_detected_at = NOW()
+WHERE id = $1;
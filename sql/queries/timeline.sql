@@ -0,0 +1,82 @@
+-- name: FanOutPost :exec
+WITH fanned_out AS (
+   UPDATE posts SET fanned_out = TRUE
+   WHERE id = sqlc.arg('post_id')::uuid
+)
+INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+SELECT follows.follower_id, sqlc.arg('post_id')::uuid, sqlc.arg('author_id')::uuid, sqlc.arg('created_at')::timestamp
+FROM follows
+WHERE follows.followee_id = sqlc.arg('author_id')::uuid
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+SELECT sqlc.arg('user_id')::uuid, recent.id, recent.user_id, recent.created_at
+FROM (
+   SELECT id, user_id, created_at FROM posts
+   WHERE posts.user_id = sqlc.arg('author_id')::uuid
+   AND parent_id IS NULL
+   AND deleted_at IS NULL
+   ORDER BY created_at DESC
+   LIMIT sqlc.arg('limit')
+) AS recent
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTimelineEntriesByAuthor :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1
+AND author_id = $2;
+
+-- name: ListTimelineDesc :many
+SELECT sqlc.embed(posts) FROM timeline_entries
+JOIN posts ON posts.id = timeline_entries.post_id
+WHERE timeline_entries.user_id = sqlc.arg('user_id')
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, sqlc.arg('user_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.arg('user_id')::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.arg('user_id')::uuid AND muted_id = posts.user_id)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (timeline_entries.created_at, timeline_entries.post_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY timeline_entries.created_at DESC, timeline_entries.post_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListTimelineAsc :many
+SELECT sqlc.embed(posts) FROM timeline_entries
+JOIN posts ON posts.id = timeline_entries.post_id
+WHERE timeline_entries.user_id = sqlc.arg('user_id')
+AND posts.deleted_at IS NULL
+AND post_visible(posts.id, sqlc.arg('user_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.arg('user_id')::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.arg('user_id')::uuid AND muted_id = posts.user_id)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (timeline_entries.created_at, timeline_entries.post_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY timeline_entries.created_at ASC, timeline_entries.post_id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListUnfannedTimelinePostsDesc :many
+SELECT * FROM posts
+WHERE (user_id = sqlc.arg('viewer_id')::uuid
+   OR (NOT fanned_out AND user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('viewer_id')::uuid)))
+AND parent_id IS NULL
+AND deleted_at IS NULL
+AND post_visible(posts.id, sqlc.arg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.arg('viewer_id')::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.arg('viewer_id')::uuid AND muted_id = posts.user_id)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListUnfannedTimelinePostsAsc :many
+SELECT * FROM posts
+WHERE (user_id = sqlc.arg('viewer_id')::uuid
+   OR (NOT fanned_out AND user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('viewer_id')::uuid)))
+AND parent_id IS NULL
+AND deleted_at IS NULL
+AND post_visible(posts.id, sqlc.arg('viewer_id')::uuid)
+AND post_visible(posts.repost_of_id, sqlc.arg('viewer_id')::uuid)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.arg('viewer_id')::uuid AND muted_id = posts.user_id)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
@@ -11,12 +11,23 @@ VALUES (
 RETURNING *;
 
 -- name: ChangeUser :one
-UPDATE users SET email = $1, updated_at = NOW(), password = $2
-WHERE id = $3
+UPDATE users SET pending_email = $1, updated_at = NOW(), password = $2, is_private = $3
+WHERE id = $4
 RETURNING *;
 
--- name: ListAllUsers :many
-SELECT id, created_at, updated_at, email, username FROM users;
+-- name: ListUsersDesc :many
+SELECT id, created_at, updated_at, email, username FROM users
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListUsersAsc :many
+SELECT id, created_at, updated_at, email, username FROM users
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL
+   OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: GetUserByID :one
 SELECT * FROM users
@@ -38,3 +49,38 @@ WHERE username = $1 or email = $2;
 UPDATE users SET is_premium = true, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
+
+-- name: SetUserRole :one
+UPDATE users SET role = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET password = $1, updated_at = NOW()
+WHERE id = $2;
+
+-- name: ConfirmUserEmail :one
+UPDATE users SET email = sqlc.arg(email), pending_email = NULL,
+email_verified_at = NOW(), updated_at = NOW()
+WHERE id = sqlc.arg(id)
+AND (email = sqlc.arg(email) OR pending_email = sqlc.arg(email))
+RETURNING *;
+
+-- name: SetTOTPSecret :execrows
+UPDATE users SET totp_secret = $1, updated_at = NOW()
+WHERE id = $2
+AND totp_enabled_at IS NULL;
+
+-- name: EnableTOTP :exec
+UPDATE users SET totp_enabled_at = NOW(), totp_last_used_step = $1, updated_at = NOW()
+WHERE id = $2;
+
+-- name: DisableTOTP :exec
+UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL,
+totp_last_used_step = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users SET totp_last_used_step = $1
+WHERE id = $2
+AND (totp_last_used_step IS NULL OR totp_last_used_step < $1);
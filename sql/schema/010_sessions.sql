@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE sessions (
+   id UUID PRIMARY KEY,
+   created_at TIMESTAMP NOT NULL,
+   updated_at TIMESTAMP NOT NULL,
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   user_agent TEXT NOT NULL,
+   ip_address TEXT NOT NULL,
+   device_name TEXT,
+   last_used_at TIMESTAMP NOT NULL,
+   revoked_at TIMESTAMP,
+   reuse_detected_at TIMESTAMP
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions(user_id);
+
+-- Every existing refresh token family becomes a session
+INSERT INTO sessions (id, created_at, updated_at, user_id, user_agent, ip_address, last_used_at, revoked_at, reuse_detected_at)
+SELECT
+   family_id,
+   MIN(created_at),
+   MAX(updated_at),
+   user_id,
+   '',
+   '',
+   MAX(updated_at),
+   CASE WHEN BOOL_AND(revoked_at IS NOT NULL) THEN MAX(revoked_at) END,
+   MAX(reuse_detected_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id;
+
+ALTER TABLE refresh_tokens
+ADD CONSTRAINT refresh_tokens_family_id_fkey
+FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+DROP CONSTRAINT refresh_tokens_family_id_fkey;
+
+DROP TABLE sessions;
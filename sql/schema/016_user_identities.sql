@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE user_identities (
+   id UUID PRIMARY KEY,
+   created_at TIMESTAMP NOT NULL,
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   provider TEXT NOT NULL,
+   subject TEXT NOT NULL,
+   email TEXT NOT NULL,
+   UNIQUE (provider, subject),
+   UNIQUE (user_id, provider)
+);
+
+CREATE TABLE oidc_login_states (
+   state_hash TEXT PRIMARY KEY,
+   created_at TIMESTAMP NOT NULL,
+   provider TEXT NOT NULL,
+   nonce TEXT NOT NULL,
+   code_verifier TEXT NOT NULL,
+   user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+   expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE oidc_login_states;
+DROP TABLE user_identities;
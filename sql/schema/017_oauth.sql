@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE oauth_clients (
+   id TEXT PRIMARY KEY,
+   created_at TIMESTAMP NOT NULL,
+   updated_at TIMESTAMP NOT NULL,
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   name TEXT NOT NULL,
+   redirect_uris TEXT[] NOT NULL,
+   secret_hash TEXT
+);
+
+CREATE INDEX oauth_clients_user_id_idx ON oauth_clients(user_id);
+
+-- A session without client is a first-party login that may do everything,
+-- one with a client is a grant limited to the scopes of its refresh tokens
+ALTER TABLE sessions
+ADD COLUMN client_id TEXT REFERENCES oauth_clients(id) ON DELETE CASCADE;
+
+ALTER TABLE refresh_tokens
+ADD COLUMN client_id TEXT REFERENCES oauth_clients(id) ON DELETE CASCADE,
+ADD COLUMN scopes TEXT[];
+
+CREATE TABLE oauth_authorization_codes (
+   code_hash TEXT PRIMARY KEY,
+   created_at TIMESTAMP NOT NULL,
+   client_id TEXT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   redirect_uri TEXT NOT NULL,
+   scopes TEXT[] NOT NULL,
+   code_challenge TEXT NOT NULL,
+   expires_at TIMESTAMP NOT NULL,
+   used_at TIMESTAMP,
+   session_id UUID REFERENCES sessions(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE oauth_authorization_codes;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN scopes,
+DROP COLUMN client_id;
+
+ALTER TABLE sessions
+DROP COLUMN client_id;
+
+DROP TABLE oauth_clients;
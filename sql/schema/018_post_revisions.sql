@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMP;
+
+CREATE TABLE post_revisions (
+   id UUID PRIMARY KEY,
+   created_at TIMESTAMP NOT NULL,
+   post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+   edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+   body TEXT NOT NULL
+);
+
+CREATE INDEX post_revisions_post_id_idx ON post_revisions(post_id, created_at);
+
+-- +goose Down
+DROP TABLE post_revisions;
+ALTER TABLE posts DROP COLUMN edited_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE INDEX posts_created_at_id_idx ON posts(created_at, id);
+CREATE INDEX posts_user_id_created_at_idx ON posts(user_id, created_at, id);
+CREATE INDEX users_created_at_id_idx ON users(created_at, id);
+CREATE INDEX reports_created_at_id_idx ON reports(created_at, report_id);
+CREATE INDEX posts_likes_created_at_id_idx ON posts_likes(created_at, id);
+CREATE INDEX posts_likes_post_id_created_at_idx ON posts_likes(post_id, created_at, id);
+
+-- +goose Down
+DROP INDEX posts_likes_post_id_created_at_idx;
+DROP INDEX posts_likes_created_at_id_idx;
+DROP INDEX reports_created_at_id_idx;
+DROP INDEX users_created_at_id_idx;
+DROP INDEX posts_user_id_created_at_idx;
+DROP INDEX posts_created_at_id_idx;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN parent_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+ADD COLUMN root_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+ADD COLUMN reply_count INT NOT NULL DEFAULT 0,
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX posts_parent_id_created_at_idx ON posts(parent_id, created_at, id);
+
+-- +goose Down
+DROP INDEX posts_parent_id_created_at_idx;
+ALTER TABLE posts
+DROP COLUMN deleted_at,
+DROP COLUMN reply_count,
+DROP COLUMN root_id,
+DROP COLUMN parent_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN repost_of_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+ADD COLUMN quote_of_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+ADD COLUMN repost_count INT NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX posts_user_id_repost_of_id_idx ON posts(user_id, repost_of_id)
+WHERE repost_of_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX posts_user_id_repost_of_id_idx;
+ALTER TABLE posts
+DROP COLUMN repost_count,
+DROP COLUMN quote_of_id,
+DROP COLUMN repost_of_id;
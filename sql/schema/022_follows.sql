@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE follows (
+   follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   created_at TIMESTAMP NOT NULL,
+   PRIMARY KEY (follower_id, followee_id),
+   CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_follower_id_created_at_idx ON follows(follower_id, created_at, followee_id);
+CREATE INDEX follows_followee_id_created_at_idx ON follows(followee_id, created_at, follower_id);
+
+ALTER TABLE users
+ADD COLUMN follower_count INT NOT NULL DEFAULT 0,
+ADD COLUMN following_count INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN following_count,
+DROP COLUMN follower_count;
+DROP TABLE follows;
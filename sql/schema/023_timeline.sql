@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE timeline_entries (
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+   author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   created_at TIMESTAMP NOT NULL,
+   PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX timeline_entries_user_id_created_at_idx ON timeline_entries(user_id, created_at, post_id);
+CREATE INDEX timeline_entries_user_id_author_id_idx ON timeline_entries(user_id, author_id);
+
+-- +goose Down
+DROP TABLE timeline_entries;
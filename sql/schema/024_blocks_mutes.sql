@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE blocks (
+   blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   created_at TIMESTAMP NOT NULL,
+   PRIMARY KEY (blocker_id, blocked_id),
+   CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks(blocked_id, blocker_id);
+CREATE INDEX blocks_blocker_id_created_at_idx ON blocks(blocker_id, created_at, blocked_id);
+
+CREATE TABLE mutes (
+   muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   created_at TIMESTAMP NOT NULL,
+   PRIMARY KEY (muter_id, muted_id),
+   CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX mutes_muter_id_created_at_idx ON mutes(muter_id, created_at, muted_id);
+
+-- users_blocked - whether either user blocked the other. False when one of
+-- them is NULL, like for a viewer that isn't logged in.
+-- +goose StatementBegin
+CREATE FUNCTION users_blocked(a UUID, b UUID) RETURNS BOOLEAN AS $$
+   SELECT EXISTS (
+      SELECT 1 FROM blocks
+      WHERE (blocker_id = a AND blocked_id = b)
+      OR (blocker_id = b AND blocked_id = a)
+   );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION users_blocked;
+DROP TABLE mutes;
+DROP TABLE blocks;
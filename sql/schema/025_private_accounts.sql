@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE follow_requests (
+   requester_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   target_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   created_at TIMESTAMP NOT NULL,
+   PRIMARY KEY (requester_id, target_id),
+   CHECK (requester_id <> target_id)
+);
+
+CREATE INDEX follow_requests_target_id_created_at_idx ON follow_requests(target_id, created_at, requester_id);
+
+-- author_visible - whether viewer can see the posts of author: they don't
+-- block each other, and a private author is only seen by themselves and their
+-- followers. True when author is NULL, like for a post that isn't a repost.
+-- +goose StatementBegin
+CREATE FUNCTION author_visible(author UUID, viewer UUID) RETURNS BOOLEAN AS $$
+   SELECT author IS NULL OR (
+      NOT users_blocked(author, viewer)
+      AND (
+         author IS NOT DISTINCT FROM viewer
+         OR NOT EXISTS (SELECT 1 FROM users WHERE id = author AND is_private)
+         OR EXISTS (SELECT 1 FROM follows WHERE follower_id = viewer AND followee_id = author)
+      )
+   );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION author_visible;
+DROP TABLE follow_requests;
+ALTER TABLE users
+DROP COLUMN is_private;
@@ -0,0 +1,41 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'followers', 'mentioned'));
+
+CREATE TABLE post_mentions (
+   post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX post_mentions_user_id_idx ON post_mentions(user_id);
+
+-- post_visible - whether viewer can see the post: its author is visible to
+-- them (see author_visible) and its visibility lets them in. True when id is
+-- NULL, like for the repost_of_id of a post that isn't a repost.
+-- +goose StatementBegin
+CREATE FUNCTION post_visible(id UUID, viewer UUID) RETURNS BOOLEAN AS $$
+   SELECT post_visible.id IS NULL OR EXISTS (
+      SELECT 1 FROM posts
+      WHERE posts.id = post_visible.id
+      AND author_visible(posts.user_id, viewer)
+      AND (
+         posts.visibility IN ('public', 'unlisted')
+         OR posts.user_id IS NOT DISTINCT FROM viewer
+         OR (posts.visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows WHERE follower_id = viewer AND followee_id = posts.user_id
+         ))
+         OR (posts.visibility = 'mentioned' AND EXISTS (
+            SELECT 1 FROM post_mentions WHERE post_mentions.post_id = posts.id AND post_mentions.user_id = viewer
+         ))
+      )
+   );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION post_visible;
+DROP TABLE post_mentions;
+ALTER TABLE posts
+DROP COLUMN visibility;
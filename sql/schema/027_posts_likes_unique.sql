@@ -0,0 +1,19 @@
+-- +goose Up
+-- Keeps the first like of every user on a post, the later ones were
+-- duplicates the old handler let through
+DELETE FROM posts_likes AS later
+USING posts_likes AS earlier
+WHERE later.post_id = earlier.post_id
+AND later.user_id = earlier.user_id
+AND (later.created_at, later.id) > (earlier.created_at, earlier.id);
+
+ALTER TABLE posts_likes
+ADD CONSTRAINT posts_likes_post_id_user_id_key UNIQUE (post_id, user_id);
+
+UPDATE posts SET likes = (
+   SELECT COUNT(*) FROM posts_likes WHERE posts_likes.post_id = posts.id
+);
+
+-- +goose Down
+ALTER TABLE posts_likes
+DROP CONSTRAINT posts_likes_post_id_user_id_key;
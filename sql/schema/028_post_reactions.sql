@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE post_reactions (
+   post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+   reaction TEXT NOT NULL,
+   created_at TIMESTAMP NOT NULL,
+   PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX post_reactions_post_id_created_at_idx ON post_reactions(post_id, created_at, user_id);
+CREATE INDEX post_reactions_post_id_reaction_created_at_idx ON post_reactions(post_id, reaction, created_at, user_id);
+
+-- reaction_counts - reactions on the post by name, reactions nobody left are
+-- left out
+ALTER TABLE posts
+ADD COLUMN reaction_counts JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN reaction_counts;
+DROP TABLE post_reactions;
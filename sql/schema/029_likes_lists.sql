@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX posts_likes_post_id_created_at_user_id_idx ON posts_likes(post_id, created_at, user_id);
+CREATE INDEX posts_likes_user_id_created_at_idx ON posts_likes(user_id, created_at, post_id);
+
+-- +goose Down
+DROP INDEX posts_likes_user_id_created_at_idx;
+DROP INDEX posts_likes_post_id_created_at_user_id_idx;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- A like is the "like" reaction, so every user has at most one reaction on a
+-- post. Reactions keep an id for the likes endpoints, likes move over with
+-- theirs.
+ALTER TABLE post_reactions
+ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid();
+
+ALTER TABLE post_reactions
+ADD CONSTRAINT post_reactions_id_key UNIQUE (id);
+
+CREATE INDEX post_reactions_user_id_reaction_created_at_idx ON post_reactions(user_id, reaction, created_at, post_id);
+
+-- A user who liked a post and also left another reaction on it keeps the
+-- other reaction
+INSERT INTO post_reactions (id, post_id, user_id, reaction, created_at)
+SELECT id, post_id, user_id, 'like', created_at FROM posts_likes
+ON CONFLICT DO NOTHING;
+
+-- posts_likes isn't used anymore, it is kept so nothing is lost and Down can
+-- bring it back
+ALTER TABLE posts_likes RENAME TO posts_likes_archive;
+
+UPDATE posts SET reaction_counts = COALESCE((
+   SELECT jsonb_object_agg(counted.reaction, counted.count) FROM (
+      SELECT reaction, COUNT(*) AS count FROM post_reactions
+      WHERE post_reactions.post_id = posts.id
+      GROUP BY reaction
+   ) AS counted
+), '{}');
+
+UPDATE posts SET likes = COALESCE((reaction_counts->>'like')::int, 0);
+
+-- +goose Down
+ALTER TABLE posts_likes_archive RENAME TO posts_likes;
+
+-- Likes given and removed since the Up are carried over, likes that gave way
+-- to another reaction come back
+DELETE FROM posts_likes
+WHERE NOT EXISTS (
+   SELECT 1 FROM post_reactions
+   WHERE post_reactions.post_id = posts_likes.post_id
+   AND post_reactions.user_id = posts_likes.user_id
+);
+
+INSERT INTO posts_likes (id, post_id, user_id, created_at)
+SELECT id, post_id, user_id, created_at FROM post_reactions
+WHERE reaction = 'like'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM post_reactions WHERE reaction = 'like';
+
+UPDATE posts SET
+   likes = (SELECT COUNT(*) FROM posts_likes WHERE posts_likes.post_id = posts.id),
+   reaction_counts = reaction_counts - 'like';
+
+DROP INDEX post_reactions_user_id_reaction_created_at_idx;
+
+ALTER TABLE post_reactions
+DROP COLUMN id;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- revoked_reason - rotated, logout or reuse. Only a rotated token that comes
+-- back means a copy of it leaked.
+ALTER TABLE refresh_tokens
+ADD COLUMN revoked_reason TEXT;
+
+UPDATE refresh_tokens SET revoked_reason = CASE
+   WHEN replaced_by IS NOT NULL THEN 'rotated'
+   WHEN reuse_detected_at IS NOT NULL THEN 'reuse'
+   ELSE 'logout'
+END
+WHERE revoked_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+DROP COLUMN revoked_reason;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- fanned_out - the post was copied into the timelines of its author's
+-- followers. The home timeline reads the other posts of followed accounts
+-- from the posts table, however many followers the author has by then.
+ALTER TABLE posts
+ADD COLUMN fanned_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE posts SET fanned_out = TRUE
+WHERE EXISTS (SELECT 1 FROM timeline_entries WHERE timeline_entries.post_id = posts.id);
+
+CREATE INDEX posts_user_id_created_at_not_fanned_out_idx ON posts(user_id, created_at, id)
+WHERE NOT fanned_out AND parent_id IS NULL;
+
+-- +goose Down
+DROP INDEX posts_user_id_created_at_not_fanned_out_idx;
+ALTER TABLE posts
+DROP COLUMN fanned_out;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- binding_hash - hash of the secret in the cookie of the browser that started
+-- the login. Pending logins can't be bound anymore, they are dropped.
+DELETE FROM oidc_login_states;
+
+ALTER TABLE oidc_login_states
+ADD COLUMN binding_hash TEXT NOT NULL;
+
+-- +goose Down
+ALTER TABLE oidc_login_states
+DROP COLUMN binding_hash;
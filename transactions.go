@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/imhasandl/go-restapi/internal/database"
+)
+
+// runInTx - runs fn with queries bound to a new transaction, and commits it
+// when fn succeeds
+func runInTx(ctx context.Context, dbConn *sql.DB, db *database.Queries, fn func(qtx *database.Queries) error) error {
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(db.WithTx(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}